@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// CostFunction scores how good a schedule is. Lower is better. Costs are
+// expressed in the same unit as time.Duration, nanoseconds, so that they are
+// comparable to the penalty the scheduler adds for requests that couldn't be
+// scheduled.
+type CostFunction interface {
+	// Cost returns the cost of schedule. It must not modify schedule.
+	Cost(schedule ScheduleView) float64
+}
+
+// CostFunc is an adapter to allow the use of ordinary functions as a
+// CostFunction.
+type CostFunc func(ScheduleView) float64
+
+// Cost calls f(schedule).
+func (f CostFunc) Cost(schedule ScheduleView) float64 {
+	return f(schedule)
+}
+
+// WeightedCost is a CostFunction with a weight.
+type WeightedCost struct {
+	Weight float64
+	CostFunction
+}
+
+// WeightedSum returns a CostFunction which is the weighted sum of costs.
+func WeightedSum(costs ...WeightedCost) CostFunction {
+	return CostFunc(func(schedule ScheduleView) float64 {
+		var sum float64
+		for _, c := range costs {
+			sum += c.Weight * c.Cost(schedule)
+		}
+		return sum
+	})
+}
+
+// Cost is an optional configuration option which changes the cost function the
+// scheduler minimizes. Defaults to DefaultCost. Use WeightedSum to combine
+// multiple cost functions.
+func Cost(f CostFunction) Config {
+	return func(c *Scheduler) {
+		c.cost = f
+	}
+}
+
+// EarlinessCost is a CostFunction which makes events as early as possible in
+// the week. Every event of every attendee costs the local wall clock time
+// between the earliest time and its start, weighted by the priority of the
+// event's request. Later occurrences of recurring meetings follow from the
+// first one and don't count.
+var EarlinessCost CostFunction = CostFunc(earlinessCost)
+
+func earlinessCost(schedule ScheduleView) float64 {
+	var score float64
+	for _, id := range schedule.Attendees() {
+		attendee := schedule.c.eventsByAttendee[id]
+		loc := schedule.Location(attendee.Attendee)
+		for _, event := range attendee.Scheduled {
+			if event.Occurrence > 0 {
+				continue
+			}
+			score += event.Request.priority() * float64(localSince(schedule.Earliest(), event.Start, loc))
+		}
+	}
+	return score
+}
+
+// FragmentationCost is a CostFunction which packs the events of every attendee
+// as tight as possible. It costs the gaps between consecutive events on the
+// same local day of an attendee. Only the parts of gaps that are within the
+// attendee's working hours count, so overnight gaps, weekends and, for
+// example, lunch breaks are free.
+var FragmentationCost CostFunction = CostFunc(fragmentationCost)
+
+func fragmentationCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, id := range schedule.Attendees() {
+		attendee := schedule.c.eventsByAttendee[id]
+		loc := schedule.Location(attendee.Attendee)
+		for i, event := range attendee.Scheduled[1:] {
+			prev := attendee.Scheduled[i]
+			if !sameLocalDay(prev.Start, event.Start, loc) || !prev.End.Before(event.Start) {
+				continue
+			}
+
+			gap := TimeInterval{prev.End, event.Start}
+			if wh := attendee.Attendee.WorkingHours; wh != nil {
+				score += wh.workingTime(gap, schedule.c.workingHoursLocation(attendee.Attendee))
+			} else {
+				score += gap.End.Sub(gap.Start)
+			}
+		}
+	}
+	return float64(score)
+}
+
+// OptionalAttendeeCost is a CostFunction which prefers schedules where
+// optional attendees can attend. Every optional attendee that can't attend a
+// meeting costs the length of the meeting.
+var OptionalAttendeeCost CostFunction = CostFunc(optionalAttendeeCost)
+
+func optionalAttendeeCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, event := range schedule.Events() {
+		score += time.Duration(len(event.UnavailableOptional)) * event.End.Sub(event.Start)
+	}
+	return float64(score)
+}
+
+// DefaultCost is the default CostFunction. It's the sum of EarlinessCost,
+// FragmentationCost, FocusTimeCost, OptionalAttendeeCost, PreferenceCost,
+// WastedSeatsCost and RoomSwitchCost.
+var DefaultCost = WeightedSum(
+	WeightedCost{1, EarlinessCost},
+	WeightedCost{1, FragmentationCost},
+	WeightedCost{1, FocusTimeCost},
+	WeightedCost{1, OptionalAttendeeCost},
+	WeightedCost{1, PreferenceCost},
+	WeightedCost{1, WastedSeatsCost},
+	WeightedCost{1, RoomSwitchCost},
+)
+
+// ScheduleView is a read-only view of a schedule that is being evaluated.
+// Slices returned by its methods must not be modified.
+type ScheduleView struct {
+	c *constructedSchedule
+}
+
+// Events returns all scheduled events.
+func (v ScheduleView) Events() []ScheduledEvent {
+	return v.c.Events
+}
+
+// Unscheduled returns all requests that couldn't be scheduled.
+func (v ScheduleView) Unscheduled() []UnscheduledRequest {
+	return v.c.Unscheduled
+}
+
+// Skipped returns all occurrences of recurring requests that were skipped.
+func (v ScheduleView) Skipped() []SkippedOccurrence {
+	return v.c.Skipped
+}
+
+// Earliest returns the earliest time a meeting could have been scheduled.
+func (v ScheduleView) Earliest() time.Time {
+	return v.c.earliest
+}
+
+// Attendees returns the ids of all attendees that have at least one scheduled
+// event, sorted.
+func (v ScheduleView) Attendees() []AttendeeID {
+	ids := make([]AttendeeID, 0, len(v.c.eventsByAttendee))
+	for id := range v.c.eventsByAttendee {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// AttendeeEvents returns the events of an attendee sorted by start time.
+func (v ScheduleView) AttendeeEvents(id AttendeeID) []ScheduledEvent {
+	e, exists := v.c.eventsByAttendee[id]
+	if !exists {
+		return nil
+	}
+	return e.Scheduled
+}
+
+// Location returns the time zone in which attendee a lives. See
+// Attendee.Location.
+func (v ScheduleView) Location(a Attendee) *time.Location {
+	return v.c.location(a)
+}
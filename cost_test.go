@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestCustomCostFunction(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+	}
+
+	// A cost function which wants the longest meeting to be as late as
+	// possible. The opposite of what DefaultCost would do.
+	lateLongMeetings := CostFunc(func(schedule ScheduleView) float64 {
+		var cost float64
+		for _, event := range schedule.Events() {
+			if event.Request == reqs[0] {
+				cost -= float64(event.Start.Sub(schedule.Earliest()))
+			}
+		}
+		return cost
+	})
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs, NGenerations(20), Cost(lateLongMeetings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scheduler.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Events) != 2 || result.Events[1].Request != reqs[0] {
+		t.Errorf("Expected the longest meeting to be last. Events:\n%s", pp.Sprint(result.Events))
+	}
+}
+
+func TestWeightedSum(t *testing.T) {
+	constant := func(c float64) CostFunction {
+		return CostFunc(func(ScheduleView) float64 { return c })
+	}
+	cost := WeightedSum(
+		WeightedCost{2, constant(3)},
+		WeightedCost{0.5, constant(10)},
+	)
+	if c := cost.Cost(ScheduleView{&constructedSchedule{}}); c != 11 {
+		t.Error("Unexpected cost. Expected: 11 Was:", c)
+	}
+}
+
+func TestScheduleView(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "b", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "a", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	view := ScheduleView{&schedule}
+
+	if ids := view.Attendees(); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Error("Expected sorted attendees. Was:", ids)
+	}
+	if n := len(view.AttendeeEvents("b")); n != 2 {
+		t.Error("Expected two events for attendee b. Was:", n)
+	}
+	if n := len(view.AttendeeEvents("c")); n != 0 {
+		t.Error("Expected no events for unknown attendee. Was:", n)
+	}
+	if !view.Earliest().Equal(now) || len(view.Events()) != 2 || len(view.Unscheduled()) != 0 {
+		t.Error("Unexpected view of schedule.")
+	}
+}
+
+func TestFragmentationOnlyCountsWorkingHours(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	lunchBreak := &WorkingHours{Windows: []WeeklyWindow{
+		{time.Monday, 9 * time.Hour, 12 * time.Hour},
+		{time.Monday, 13 * time.Hour, 17 * time.Hour},
+		{time.Tuesday, 9 * time.Hour, 17 * time.Hour},
+	}}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar, WorkingHours: lunchBreak}
+	req := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee}}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	at := func(d time.Duration) ScheduledEvent {
+		return ScheduledEvent{TimeInterval: TimeInterval{now.Add(d), now.Add(d + req.Length)}, Request: req}
+	}
+	schedule := constructedSchedule{
+		earliest: now,
+		eventsByAttendee: map[AttendeeID]*attendeeEvents{
+			attendee.ID: {attendee, []ScheduledEvent{
+				// 10-11 and 14-15 on Monday. 11-14 minus lunch is 2 hours.
+				at(1 * time.Hour),
+				at(5 * time.Hour),
+				// 16-17 on Monday, an hour after the previous one.
+				at(7 * time.Hour),
+				// 9-10 on Tuesday. The night doesn't count.
+				at(24 * time.Hour),
+			}},
+		},
+	}
+
+	if c, expected := FragmentationCost.Cost(ScheduleView{&schedule}), float64(2*time.Hour+1*time.Hour); c != expected {
+		t.Error("Unexpected fragmentation. Expected:", time.Duration(expected), "Was:", time.Duration(c))
+	}
+}
+
+func TestPriority(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms, Priority: 3},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+
+	for _, options := range [][]Config{
+		nil,
+		// Only room for one of the meetings.
+		{Latest(now.Add(60 * time.Minute))},
+	} {
+		scheduler, err := New(now, reqs, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		normalFirst := candidate{scheduler: scheduler, order: []int{0, 1}}
+		priorityFirst := candidate{scheduler: scheduler, order: []int{1, 0}}
+		normalFirstCost, err := normalFirst.Evaluate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		priorityFirstCost, err := priorityFirst.Evaluate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priorityFirstCost >= normalFirstCost {
+			t.Errorf("Expected the high priority meeting to be placed first with %d options. Costs: %f >= %f", len(options), priorityFirstCost, normalFirstCost)
+		}
+	}
+}
+
+func TestInfiniteCost(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+	}
+	infeasible := CostFunc(func(ScheduleView) float64 { return math.Inf(1) })
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs, NGenerations(5), Cost(infeasible))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scheduler.Run(); err == nil {
+		t.Error("Expected an error when no schedule has a finite cost.")
+	}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// Dependency is an ordering constraint between two requests, such as "design
+// review before the planning meeting" or "follow-up at least 2 days after the
+// kickoff".
+type Dependency struct {
+	// Request is the request whose meeting must take place first.
+	Request *ScheduleRequest
+	// MinGap is the minimum time between the end of the meeting of Request
+	// and the start of the dependent meeting.
+	MinGap time.Duration
+	// MaxGap is the maximum time between the end of the meeting of Request
+	// and the start of the dependent meeting. Zero means no limit.
+	MaxGap time.Duration
+}
+
+// dependencies returns, for every request in reqs, the indices of the requests
+// in reqs that it depends on. Dependencies outside of reqs are left out.
+func dependencies(reqs []*ScheduleRequest) [][]int {
+	index := make(map[*ScheduleRequest]int, len(reqs))
+	for i, req := range reqs {
+		index[req] = i
+	}
+	deps := make([][]int, len(reqs))
+	for i, req := range reqs {
+		for _, dep := range req.After {
+			if j, ok := index[dep.Request]; ok {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps
+}
+
+// layout returns order with every request moved after the requests it depends
+// on, otherwise keeping the order of the permutation. This way every ordering
+// the genetic algorithm comes up with is feasible. It also returns the
+// requests that are part of, or depend on, circular dependencies.
+func (s *Scheduler) layout(order []int) ([]int, []int) {
+	done := make([]bool, len(s.reqs))
+	ready := func(i int) bool {
+		for _, j := range s.dependencies[i] {
+			if !done[j] {
+				return false
+			}
+		}
+		return true
+	}
+
+	result := make([]int, 0, len(order))
+	for len(result) < len(order) {
+		found := false
+		for _, i := range order {
+			if !done[i] && ready(i) {
+				done[i] = true
+				result = append(result, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	var circular []int
+	for _, i := range order {
+		if !done[i] {
+			circular = append(circular, i)
+		}
+	}
+	return result, circular
+}
+
+// dependencyBounds returns the earliest and the latest time at which req can
+// start given the placement of the meetings it depends on. The latest time is
+// zero if there is no such time. Dependencies that haven't been placed are
+// ignored.
+func (c *constructedSchedule) dependencyBounds(req *ScheduleRequest) (time.Time, time.Time) {
+	var earliest, latestStart time.Time
+	for _, dep := range req.After {
+		placed, ok := c.placed[dep.Request]
+		if !ok {
+			continue
+		}
+		earliest = latest(earliest, placed.End.Add(dep.MinGap))
+		if dep.MaxGap > 0 {
+			bound := placed.End.Add(dep.MaxGap)
+			if latestStart.IsZero() || bound.Before(latestStart) {
+				latestStart = bound
+			}
+		}
+	}
+	return earliest, latestStart
+}
+
+// dependenciesPlaced checks if all of the requests in reqs with indices deps
+// have been placed.
+func (c *constructedSchedule) dependenciesPlaced(deps []int, reqs []*ScheduleRequest) bool {
+	for _, j := range deps {
+		if _, ok := c.placed[reqs[j]]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestDependencies(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: FakeCalendar{{now.Add(60 * time.Minute), now.Add(8 * time.Hour)}}}
+
+	kickoff := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms}
+	followUp := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms, After: []Dependency{{Request: kickoff, MinGap: 48 * time.Hour}}}
+	tooLate := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee2}, PossibleRooms: rooms, After: []Dependency{{Request: kickoff, MaxGap: 2 * time.Hour}}}
+	roomless := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}}
+	dependent := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms, After: []Dependency{{Request: roomless}}}
+	circular1 := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms}
+	circular2 := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms, After: []Dependency{{Request: circular1}}}
+	circular1.After = []Dependency{{Request: circular2}}
+	reqs := []*ScheduleRequest{kickoff, followUp, tooLate, roomless, dependent, circular1, circular2}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dependent requests are first in the permutation.
+	sol := candidate{scheduler: scheduler, order: []int{6, 5, 4, 3, 2, 1, 0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule.Events) != 2 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	if e := schedule.Events[0]; e.Request != kickoff || !e.Start.Equal(now) {
+		t.Errorf("Expected the kickoff to be placed first:\n%s", pp.Sprint(e))
+	}
+	if e, expected := schedule.Events[1], now.Add(49*time.Hour); e.Request != followUp || !e.Start.Equal(expected) {
+		t.Errorf("Expected the follow-up to start at %s:\n%s", expected, pp.Sprint(e))
+	}
+
+	expected := map[*ScheduleRequest]UnscheduledReason{
+		tooLate:   ExceededHorizon,
+		roomless:  NoRoomAvailable,
+		dependent: DependencyNotScheduled,
+		circular1: DependencyNotScheduled,
+		circular2: DependencyNotScheduled,
+	}
+	if len(schedule.Unscheduled) != len(expected) {
+		t.Fatalf("Unexpected unscheduled requests:\n%s", pp.Sprint(schedule.Unscheduled))
+	}
+	for _, u := range schedule.Unscheduled {
+		if u.Reason != expected[u.Request] {
+			t.Errorf("Unexpected reason. Expected: %s Was: %s", expected[u.Request], u.Reason)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// FocusTime is a policy that protects an attendee's time for focused work from
+// being chopped up by meetings.
+type FocusTime struct {
+	// MinBlock is the length of the contiguous block without meetings that
+	// the attendee wants every day. Blocks are looked for within the
+	// attendee's working hours, or the whole local day if the attendee has
+	// none. Only meetings placed by the scheduler are taken into account.
+	// Zero disables it.
+	MinBlock time.Duration
+	// Protected are weekly recurring windows during which the attendee
+	// doesn't want any meetings. They are interpreted in the same location as
+	// the attendee's working hours.
+	Protected []WeeklyWindow
+	// Hard makes the scheduler never place meetings that violate the policy.
+	// Otherwise violations are only penalized by FocusTimeCost.
+	Hard bool
+}
+
+// FocusTimeCost is a CostFunction which penalizes violations of attendees'
+// FocusTime policies. Every day that lacks a long enough block costs how much
+// shorter than FocusTime.MinBlock its longest block is, and meetings cost the
+// time they overlap with protected windows.
+var FocusTimeCost CostFunction = CostFunc(focusTimeCost)
+
+func focusTimeCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, id := range schedule.Attendees() {
+		attendee := schedule.c.eventsByAttendee[id]
+		policy := attendee.Attendee.FocusTime
+		if policy == nil {
+			continue
+		}
+		loc := schedule.c.workingHoursLocation(attendee.Attendee)
+
+		for _, event := range attendee.Scheduled {
+			for _, window := range occurrences(policy.Protected, event.TimeInterval, loc) {
+				score += earliestOf(window.End, event.End).Sub(latest(window.Start, event.Start))
+			}
+		}
+
+		if policy.MinBlock <= 0 {
+			continue
+		}
+		for i, event := range attendee.Scheduled {
+			if i > 0 && sameLocalDay(attendee.Scheduled[i-1].Start, event.Start, loc) {
+				continue
+			}
+			periods := schedule.c.workingPeriods(attendee.Attendee, event.Start)
+			if longest := longestFreeBlock(periods, attendee.Scheduled); longest < policy.MinBlock {
+				score += policy.MinBlock - longest
+			}
+		}
+	}
+	return float64(score)
+}
+
+// findFocusTimeConflict checks if se violates a hard FocusTime policy of one of
+// its attendees. If it does, it returns the next time to try.
+func (c *constructedSchedule) findFocusTimeConflict(se ScheduledEvent) (time.Time, bool) {
+	for _, a := range se.Attendees {
+		policy := a.FocusTime
+		if policy == nil || !policy.Hard {
+			continue
+		}
+		loc := c.workingHoursLocation(a)
+
+		if windows := occurrences(policy.Protected, se.TimeInterval, loc); len(windows) > 0 {
+			next := se.Start
+			for _, w := range windows {
+				next = latest(next, w.End)
+			}
+			return next, true
+		}
+
+		if policy.MinBlock <= 0 {
+			continue
+		}
+		var scheduled []ScheduledEvent
+		if e, exists := c.eventsByAttendee[a.ID]; exists {
+			scheduled = e.Scheduled
+		}
+		periods := c.workingPeriods(a, se.Start)
+		before := longestFreeBlock(periods, scheduled)
+		after := longestFreeBlock(periods, append(append([]ScheduledEvent(nil), scheduled...), se))
+		// Days that never had a long enough block aren't blamed on se.
+		if before >= policy.MinBlock && after < policy.MinBlock {
+			return nextFreeStart(se.Start, periods, scheduled, loc), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextFreeStart returns the earliest end of any of events or periods after t,
+// which is the next time at which a meeting can split the free time of the
+// day differently. If there is none, it returns the next local midnight.
+func nextFreeStart(t time.Time, periods []TimeInterval, events []ScheduledEvent, loc *time.Location) time.Time {
+	next := localMidnight(t, loc).AddDate(0, 0, 1)
+	for _, p := range periods {
+		if p.End.After(t) {
+			next = earliestOf(next, p.End)
+		}
+	}
+	for _, e := range events {
+		if e.End.After(t) {
+			next = earliestOf(next, e.End)
+		}
+	}
+	return next
+}
+
+// workingPeriods returns the periods on the local calendar day of day during
+// which attendee a works.
+func (c *constructedSchedule) workingPeriods(a Attendee, day time.Time) []TimeInterval {
+	loc := c.workingHoursLocation(a)
+	midnight := localMidnight(day, loc)
+	if a.WorkingHours == nil {
+		return []TimeInterval{{midnight, midnight.AddDate(0, 0, 1)}}
+	}
+	var periods []TimeInterval
+	for _, w := range a.WorkingHours.Windows {
+		if occurrence, ok := w.on(midnight, loc); ok {
+			periods = append(periods, occurrence)
+		}
+	}
+	return periods
+}
+
+// longestFreeBlock returns the longest time within any of periods that doesn't
+// overlap with events.
+func longestFreeBlock(periods []TimeInterval, events []ScheduledEvent) time.Duration {
+	busy := make([]TimeInterval, 0, len(events))
+	for _, e := range events {
+		busy = append(busy, e.TimeInterval)
+	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].Start.Before(busy[j].Start) })
+
+	var longest time.Duration
+	for _, period := range periods {
+		free := period.Start
+		for _, b := range busy {
+			if !b.Overlaps(period) {
+				continue
+			}
+			if gap := b.Start.Sub(free); gap > longest {
+				longest = gap
+			}
+			free = latest(free, b.End)
+		}
+		if gap := period.End.Sub(free); gap > longest {
+			longest = gap
+		}
+	}
+	return longest
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHardProtectedFocusTime(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	focus := &FocusTime{
+		Protected: []WeeklyWindow{{time.Monday, 9 * time.Hour, 12 * time.Hour}},
+		Hard:      true,
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar, FocusTime: focus}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s, expected := schedule.Events[0].Start, now.Add(3*time.Hour); !s.Equal(expected) {
+		t.Error("Expected the meeting after the protected window. Expected:", expected, "Was:", s)
+	}
+}
+
+func TestHardMinimumFocusBlock(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee := Attendee{
+		ID:           "a",
+		Calendar:     emptyCalendar,
+		WorkingHours: WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour),
+		FocusTime:    &FocusTime{MinBlock: 4 * time.Hour, Hard: true},
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 2 * time.Hour, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		{Length: 2 * time.Hour, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		{Length: 2 * time.Hour, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The third meeting would leave only two free hours on Monday.
+	expected := []time.Time{now, now.Add(2 * time.Hour), now.AddDate(0, 0, 1)}
+	for i, e := range expected {
+		if s := schedule.Events[i].Start; !s.Equal(e) {
+			t.Errorf("Unexpected start of event %d. Expected: %s Was: %s", i, e, s)
+		}
+	}
+}
+
+func TestHardMinimumFocusBlockLaterSameDay(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee := Attendee{
+		ID:           "a",
+		Calendar:     emptyCalendar,
+		WorkingHours: WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour),
+		FocusTime:    &FocusTime{MinBlock: 3 * time.Hour, Hard: true},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, NotBefore: now.Add(5 * time.Hour)},
+		{Length: 2 * time.Hour, Attendees: []Attendee{attendee}, PossibleRooms: rooms, NotBefore: now.Add(2 * time.Hour)},
+	}
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Between 11 and 14 the third meeting would leave no three hour block,
+	// but after the meeting at 14 the morning is still free.
+	expected := []time.Time{now, now.Add(5 * time.Hour), now.Add(6 * time.Hour)}
+	for i, e := range expected {
+		if s := schedule.Events[i].Start; !s.Equal(e) {
+			t.Errorf("Unexpected start of event %d. Expected: %s Was: %s", i, e, s)
+		}
+	}
+}
+
+func TestSoftFocusTimeIsPenalized(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	focus := &FocusTime{
+		MinBlock:  3 * time.Hour,
+		Protected: []WeeklyWindow{{time.Monday, 9 * time.Hour, 12 * time.Hour}},
+	}
+	attendee := Attendee{
+		ID:           "a",
+		Calendar:     emptyCalendar,
+		WorkingHours: WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour),
+		FocusTime:    focus,
+	}
+	req := &ScheduleRequest{Length: 60 * time.Minute, Attendees: []Attendee{attendee}}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	cost := func(starts ...time.Duration) float64 {
+		e := &attendeeEvents{Attendee: attendee}
+		for _, s := range starts {
+			e.Scheduled = append(e.Scheduled, ScheduledEvent{
+				TimeInterval: TimeInterval{now.Add(s), now.Add(s + req.Length)},
+				Request:      req,
+			})
+		}
+		schedule := constructedSchedule{
+			earliest:         now,
+			eventsByAttendee: map[AttendeeID]*attendeeEvents{attendee.ID: e},
+		}
+		return FocusTimeCost.Cost(ScheduleView{&schedule})
+	}
+
+	if c := cost(3 * time.Hour); c != 0 {
+		t.Error("Expected no cost for a meeting 12-13. Was:", time.Duration(c))
+	}
+	if c, expected := cost(2*time.Hour+30*time.Minute), float64(30*time.Minute); c != expected {
+		t.Error("Unexpected cost for overlapping the protected window. Expected:", time.Duration(expected), "Was:", time.Duration(c))
+	}
+	// 11-12 and 14-15 overlap an hour with the protected window and leave at
+	// most two free hours in a row.
+	if c, expected := cost(2*time.Hour, 5*time.Hour), float64(2*time.Hour); c != expected {
+		t.Error("Unexpected cost for a fragmented day. Expected:", time.Duration(expected), "Was:", time.Duration(c))
+	}
+}
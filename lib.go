@@ -20,8 +20,10 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/MaxHalford/eaopt"
@@ -37,6 +39,25 @@ type Attendee struct {
 	// Calendar is an instance of the attendee's calendar containing previously
 	// scheduled meetings.
 	Calendar Calendar
+	// WorkingHours are the hours during which the attendee can attend
+	// meetings. If nil, the attendee is considered to always be available.
+	WorkingHours *WorkingHours
+	// Location is the time zone in which the attendee lives. It defines the
+	// attendee's local calendar days. If nil, the location of WorkingHours is
+	// used and, if that isn't set either, the location of the earliest time
+	// given to New.
+	Location *time.Location
+	// FocusTime is the attendee's policy for protecting time for focused
+	// work. Optional.
+	FocusTime *FocusTime
+	// Limits caps how much the attendee meets per day. Optional.
+	Limits *MeetingLimits
+	// Buffer is the minimum pause the attendee needs between meetings. The
+	// larger of it and the Buffer option of the Scheduler is used.
+	Buffer time.Duration
+	// Remote makes the attendee join meetings over video. Remote attendees
+	// don't need a seat in the room and don't travel between rooms.
+	Remote bool
 }
 
 // TimeInterval holds an interval of time.
@@ -64,18 +85,40 @@ func (ti TimeInterval) Overlaps(ti2 TimeInterval) bool {
 	return true
 }
 
+// padded returns the interval extended by d in both directions.
+func (ti TimeInterval) padded(d time.Duration) TimeInterval {
+	return TimeInterval{ti.Start.Add(-d), ti.End.Add(d)}
+}
+
 // ScheduledEvent is an event which has been scheduled with a fixed time and
 // rooms. It is the scheduled equivalent of a ScheduleRequest.
 type ScheduledEvent struct {
 	// TimeInterval is the time over which this event has been scheduled.
 	TimeInterval
-	// Attendees is a list of the attendees for this meeting.
+	// Attendees is a list of the attendees for this meeting. It contains the
+	// required attendees, the members picked from the request's pools and the
+	// optional attendees that are available.
 	Attendees []Attendee
-	// Room is the room in which the event will take place.
+	// UnavailableOptional is a list of the optional attendees that can't
+	// attend the meeting, because they are busy or because there are no seats
+	// left for them in the room.
+	UnavailableOptional []Attendee
+	// Room is the room in which the event will take place. It's the zero
+	// Room for meetings that take place over video only.
 	Room Room
+	// VideoLink is the link to the video call of the event if it's virtual
+	// or has remote attendees. It's only set if the VideoLinks option is
+	// used.
+	VideoLink string
 	// Request is the equivalent ScheduleRequest that generated this
 	// ScheduledEvent.
 	Request *ScheduleRequest
+	// Session is the index of the session this event is if Request was
+	// split into several sessions.
+	Session int
+	// Occurrence is the index of the occurrence this event is if Request is
+	// recurring.
+	Occurrence int
 }
 
 // ScheduleRequest is the input the scheduling. It's a request to schedule a
@@ -83,12 +126,112 @@ type ScheduledEvent struct {
 type ScheduleRequest struct {
 	// Length is the requested length of the meeting.
 	Length time.Duration
-	// Attendees is a list of the attendees of the meeting.
+	// Attendees is a list of the required attendees of the meeting. The
+	// meeting is only scheduled when all of them are available.
 	Attendees []Attendee
+	// Pools are groups of interchangeable attendees of which only some are
+	// required to attend. The members that attend are picked when the
+	// meeting is placed.
+	Pools []AttendeePool
+	// OptionalAttendees is a list of attendees that are invited if they are
+	// available, but that don't prevent the meeting from being scheduled.
+	OptionalAttendees []Attendee
 	// PossibleRooms is a list of the possible rooms in which the meetings can
 	// take place. If you have multiple offices you might want to limit which
 	// rooms a meeting can take place in.
 	PossibleRooms []Room
+	// Mode is how the attendees meet. Defaults to InPerson.
+	Mode MeetingMode
+	// RequiredFeatures are the features a room must have for the meeting to
+	// take place in it. Rooms of PossibleRooms without them are ignored.
+	RequiredFeatures []RoomFeature
+	// NotBefore is the time at which the meeting can start at the earliest.
+	// Optional.
+	NotBefore time.Time
+	// Deadline is the time at which the meeting must have ended at the
+	// latest. Optional.
+	Deadline time.Time
+	// Preference is a soft preference for when during the week the meeting
+	// takes place. Optional.
+	Preference *TimePreference
+	// Split allows the meeting to be split into several sessions. It's
+	// ignored for recurring meetings. Optional.
+	Split *Split
+	// Recurrence makes the request schedule a series of meetings. Only the
+	// first occurrence must end before the Deadline and the latest time of
+	// the Scheduler. Optional.
+	Recurrence *Recurrence
+	// After are the requests whose meetings must take place before this one.
+	// Requests that aren't scheduled by the same Scheduler are ignored.
+	After []Dependency
+	// Priority weights how costly it is to delay the meeting and to not
+	// schedule it at all. Meetings with a higher priority are placed earlier
+	// and are the last to be dropped when the horizon is full. Zero or less
+	// means the default priority 1.
+	Priority float64
+}
+
+// priority returns the effective priority of req.
+func (req *ScheduleRequest) priority() float64 {
+	if req.Priority <= 0 {
+		return 1
+	}
+	return req.Priority
+}
+
+// UnscheduledReason is a machine-readable reason for why a ScheduleRequest
+// couldn't be scheduled.
+type UnscheduledReason string
+
+const (
+	// NoCommonFreeTime means that the attendees of the request never were
+	// available at the same time.
+	NoCommonFreeTime UnscheduledReason = "no_common_free_time"
+	// NoRoomAvailable means that none of the possible rooms of the request
+	// that are large enough and have the required features were available
+	// when the attendees were.
+	NoRoomAvailable UnscheduledReason = "no_room_available"
+	// ExceededHorizon means that the request didn't fit before the latest
+	// time of the Scheduler, the Deadline of the request or the MaxGap of one
+	// of its dependencies.
+	ExceededHorizon UnscheduledReason = "exceeded_horizon"
+	// CalendarError means that looking up an attendee's or a room's calendar
+	// failed.
+	CalendarError UnscheduledReason = "calendar_error"
+	// DependencyNotScheduled means that a request the request depends on
+	// couldn't be scheduled, or that the dependencies are circular.
+	DependencyNotScheduled UnscheduledReason = "dependency_not_scheduled"
+	// Excepted means that an occurrence of a recurring request was skipped
+	// because it's on a day in Recurrence.Except.
+	Excepted UnscheduledReason = "excepted"
+)
+
+// UnscheduledRequest is a ScheduleRequest that couldn't be scheduled.
+type UnscheduledRequest struct {
+	// Request is the request that couldn't be scheduled.
+	Request *ScheduleRequest
+	// Reason is the reason why Request couldn't be scheduled.
+	Reason UnscheduledReason
+	// Err is the underlying error, if any. It's set when Reason is
+	// CalendarError.
+	Err error
+}
+
+// Result is the outcome of running a Scheduler.
+type Result struct {
+	// Events are the events that were scheduled.
+	Events []ScheduledEvent
+	// Unscheduled are the requests that couldn't be scheduled.
+	Unscheduled []UnscheduledRequest
+	// Skipped are the occurrences of recurring requests that were skipped.
+	Skipped []SkippedOccurrence
+	// StopReason is the reason why the genetic algorithm stopped.
+	StopReason StopReason
+	// Generations is the number of generations that were executed.
+	Generations uint
+	// Seed is the seed of the random number generator that was used. Passing
+	// it to the Seed option reproduces the run.
+	Seed int64
 }
 
 // CalendarEvent is an event stored in a calendar.
@@ -103,6 +246,27 @@ type Calendar interface {
 	Overlap(TimeInterval) (*CalendarEvent, bool, error)
 }
 
+// ContextCalendar is a Calendar whose lookups can be aborted. It is useful
+// for slow, remote calendars. If a Calendar implements ContextCalendar,
+// OverlapContext is used instead of Overlap.
+type ContextCalendar interface {
+	Calendar
+	// OverlapContext is like Overlap but aborts when ctx is done.
+	OverlapContext(context.Context, TimeInterval) (*CalendarEvent, bool, error)
+}
+
+// overlap checks if ti overlaps with a preexisting event in cal, aborting if
+// ctx is done.
+func overlap(ctx context.Context, cal Calendar, ti TimeInterval) (*CalendarEvent, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if cc, ok := cal.(ContextCalendar); ok {
+		return cc.OverlapContext(ctx, ti)
+	}
+	return cal.Overlap(ti)
+}
+
 // RoomID is a unique id for a room.
 type RoomID string
 
@@ -113,6 +277,18 @@ type Room struct {
 	ID RoomID
 	// Calendar is the calendar of the room.
 	Calendar Calendar
+	// Buffer is the time needed between two bookings of the room, for
+	// example for cleaning or setting up.
+	Buffer time.Duration
+	// Capacity is the number of seats in the room. Meetings with more
+	// required attendees than seats are never placed in it. Zero means
+	// unknown.
+	Capacity int
+	// Features are the features of the room, such as video conferencing.
+	Features []RoomFeature
+	// Building is the building the room is in. It's used to look up travel
+	// times between rooms. Optional.
+	Building BuildingID
 }
 
 // DefaultNGenerations is the number of generations that the genetic algorithm
@@ -131,22 +307,80 @@ func NGenerations(ngenerations uint) Config {
 	}
 }
 
+// Latest is an optional configuration option which sets the latest time at
+// which a meeting can end. Requests that can't be scheduled before it are
+// returned as unscheduled by Scheduler.Run.
+func Latest(latest time.Time) Config {
+	return func(c *Scheduler) {
+		c.latest = latest
+	}
+}
+
+// Buffer is an optional configuration option which sets the minimum pause all
+// attendees need between meetings. It applies both to preexisting events in
+// their calendars and to meetings placed by the scheduler. See also
+// Attendee.Buffer and Room.Buffer.
+func Buffer(d time.Duration) Config {
+	return func(c *Scheduler) {
+		c.buffer = d
+	}
+}
+
+// Travel is an optional configuration option which sets the time it takes to
+// travel between buildings. Attendees always get at least that much time
+// between consecutive meetings in rooms in different buildings.
+func Travel(m TravelMatrix) Config {
+	return func(c *Scheduler) {
+		c.travel = m
+	}
+}
+
+// Seed is an optional configuration option which seeds the random number
+// generator of the genetic algorithm. Two runs with the same seed and input
+// produce the same schedule, unless they are stopped early by TimeBudget or a
+// context. If not set, a seed is picked based on the current time.
+func Seed(seed int64) Config {
+	return func(c *Scheduler) {
+		c.seed = &seed
+	}
+}
+
 // New instantiates a new meeting scheduler that tries to schedule meeting
 // requests, reqs, as close as possible to earliest which also minimizing
 // attendee calendar fragmentation (that is, an attendee has a break of 45
 // minutes between meetings).
 //
-// If you'd like your attendees to have pauses between their meetings, simulate
-// that in Calendar.Overlap.
+// If you'd like your attendees to have pauses between their meetings, use the
+// Buffer option or Attendee.Buffer.
 func New(earliest time.Time, reqs []*ScheduleRequest, options ...Config) (*Scheduler, error) {
 	s := Scheduler{
-		DefaultNGenerations,
-		earliest,
-		reqs,
+		ngenerations: DefaultNGenerations,
+		swaps:        1,
+		earliest:     earliest,
+		reqs:         reqs,
+		dependencies: dependencies(reqs),
 	}
 	for _, o := range options {
 		o(&s)
 	}
+	if !s.latest.IsZero() && !s.latest.After(earliest) {
+		return nil, errors.New("latest must be after earliest")
+	}
+	if s.minImprovement != 0 && s.maxStagnation == 0 {
+		return nil, errors.New("MinImprovement requires MaxStagnation")
+	}
+	for _, req := range reqs {
+		if req.Recurrence != nil {
+			if err := req.Recurrence.validate(); err != nil {
+				return nil, err
+			}
+		}
+		for _, pool := range req.Pools {
+			if err := pool.validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return &s, nil
 }
 
@@ -154,46 +388,113 @@ func New(earliest time.Time, reqs []*ScheduleRequest, options ...Config) (*Sched
 type Scheduler struct {
 	ngenerations uint
 	earliest     time.Time
+	latest       time.Time
 	reqs         []*ScheduleRequest
+	dependencies [][]int
+	progress     func(Progress)
+	seed         *int64
+	cost         CostFunction
+	buffer       time.Duration
+	travel       TravelMatrix
+	videoLinks   VideoLinkFunc
+
+	popSize      uint
+	npops        uint
+	migFrequency uint
+	migrants     uint
+	selector     eaopt.Selector
+	mutRate      *float64
+	crossRate    *float64
+	swaps        int
+	crossover    CrossoverOperator
+	hofSize      uint
+
+	maxStagnation  uint
+	minImprovement float64
+	timeBudget     time.Duration
 }
 
 // Run executes scheduling of meetings.
-func (s *Scheduler) Run() ([]ScheduledEvent, error) {
-	// Instantiate a GA with a GAConfig
-	ga, err := eaopt.NewDefaultGAConfig().NewGA()
+func (s *Scheduler) Run() (*Result, error) {
+	return s.RunContext(context.Background())
+}
+
+// RunContext executes scheduling of meetings. When ctx is done, the genetic
+// algorithm stops after the current generation and the best schedule found so
+// far is returned. ctx is also passed on to calendars implementing
+// ContextCalendar.
+func (s *Scheduler) RunContext(ctx context.Context) (*Result, error) {
+	seed := time.Now().UnixNano()
+	if s.seed != nil {
+		seed = *s.seed
+	}
+
+	ga, err := s.newGA(rand.New(rand.NewSource(seed)))
 	if err != nil {
 		return nil, err
 	}
 
-	// Set the number of generations to run for
-	ga.NGenerations = s.ngenerations
+	stopper := newStopper(s, ctx)
+	ga.EarlyStop = stopper.stop
 
-	// Add a custom print function to track progress
-	// TODO: Make this callback(ish) be definable as an Config option.
-	/*ga.Callback = func(ga *eaopt.GA) {
-		fmt.Printf("Best fitness at generation %d: %f\n", ga.Generations, ga.HallOfFame[0].Fitness)
-	}*/
-
-	// TODO: Stop early if no progress is being made.
+	if s.progress != nil {
+		started := time.Now()
+		ga.Callback = func(ga *eaopt.GA) {
+			s.progress(newProgress(ga, started))
+		}
+	}
 
 	// Find the minimum
-	err = ga.Minimize(s.scheduleFactory)
-	if err != nil {
-		return nil, err
-	}
+	err = ga.Minimize(func(rng *rand.Rand) eaopt.Genome {
+		c := s.scheduleFactory(rng)
+		c.ctx = ctx
+		return c
+	})
 
 	// Assuming the first individual is the best -
 	// https://godoc.org/github.com/MaxHalford/eaopt#GA isn't too well
 	// documented.
-	schedule, err := ga.HallOfFame[0].Genome.(*candidate).Schedule()
+	var best *candidate
+	if len(ga.HallOfFame) > 0 {
+		best, _ = ga.HallOfFame[0].Genome.(*candidate)
+	}
+	if err != nil && (ctx.Err() == nil || best == nil) {
+		// Unless we were cancelled mid-generation and have a best candidate
+		// from a previous generation to fall back to.
+		return nil, err
+	}
 	if err != nil {
+		stopper.reason = Cancelled
+	}
+	if best == nil {
+		// Every candidate had an infinite or NaN cost.
+		return nil, errors.New("no candidate with a finite cost was found")
+	}
+	if best.schedule == nil {
+		return nil, errors.New("best candidate was never evaluated")
+	}
+	schedule := best.schedule
+	events, err := s.addVideoLinks(ctx, schedule.Events)
+	if err != nil && ctx.Err() == nil {
 		return nil, err
 	}
-	return schedule.Events, nil
+	if ctx.Err() != nil {
+		// Keep the best schedule, with the links generated before the
+		// context was done.
+		stopper.reason = Cancelled
+	}
+	return &Result{
+		Events:      events,
+		Unscheduled: schedule.Unscheduled,
+		Skipped:     schedule.Skipped,
+		StopReason:  stopper.reason,
+		Generations: ga.Generations,
+		Seed:        seed,
+	}, nil
 }
 
 // ScheduleFactory generates a viable schedule candidate.
-func (s *Scheduler) scheduleFactory(rng *rand.Rand) eaopt.Genome {
+func (s *Scheduler) scheduleFactory(rng *rand.Rand) *candidate {
 	order := make([]int, len(s.reqs))
 	for i := 0; i < len(s.reqs); i++ {
 		order[i] = i
@@ -202,9 +503,8 @@ func (s *Scheduler) scheduleFactory(rng *rand.Rand) eaopt.Genome {
 		order[i], order[j] = order[j], order[i]
 	})
 	return &candidate{
-		s.earliest,
-		s.reqs,
-		order,
+		scheduler: s,
+		order:     order,
 	}
 }
 
@@ -212,21 +512,31 @@ func (s *Scheduler) scheduleFactory(rng *rand.Rand) eaopt.Genome {
 // depicts "a set of scheduled meetings". The candidate might not be the
 // optimal schedule. candidate implements `eaopt.Genome`.
 type candidate struct {
-	earliest time.Time
-	reqs     []*ScheduleRequest
+	scheduler *Scheduler
+	// ctx is the context of the run the candidate belongs to. It's stored
+	// here since eaopt.Genome doesn't take a context. Background if nil.
+	ctx context.Context
 
 	// This is the order we are optimizing for. We could in theory really
-	// reorder reqs, but since eaopt requires that slices's interface{} content
-	// is hashable we reorder ints which really are the indexes of reqs.
+	// reorder scheduler.reqs, but since eaopt requires that slices's
+	// interface{} content is hashable we reorder ints which really are the
+	// indexes of scheduler.reqs.
 	order []int
+
+	// schedule is the schedule constructed when the candidate was last
+	// evaluated. It's kept to not have to construct the best schedule again,
+	// which might not be possible once ctx is done. nil if order has changed
+	// since.
+	schedule *constructedSchedule
 }
 
 // Clone makes a copy of a candidate.
 func (s *candidate) Clone() eaopt.Genome {
 	return &candidate{
-		s.earliest,
-		s.reqs,
+		s.scheduler,
+		s.ctx,
 		append([]int(nil), s.order...),
+		s.schedule,
 	}
 }
 
@@ -234,24 +544,43 @@ func (s *candidate) Clone() eaopt.Genome {
 // solutions mating (and one parent, weirdly, being replaced by its child).
 func (s *candidate) Crossover(genome eaopt.Genome, rng *rand.Rand) {
 	// https://www.hindawi.com/journals/cin/2017/7430125/
-	eaopt.CrossCXInt(s.order, genome.(*candidate).order)
+	other := genome.(*candidate)
+	if len(s.order) < 2 {
+		// There is nothing to cross over and eaopt's PMX and OX assume at
+		// least two genes.
+		return
+	}
+	switch s.scheduler.crossover {
+	case PartiallyMappedCrossover:
+		eaopt.CrossPMXInt(s.order, other.order, rng)
+	case OrderedCrossover:
+		eaopt.CrossOXInt(s.order, other.order, rng)
+	default:
+		eaopt.CrossCXInt(s.order, other.order)
+	}
+	s.schedule, other.schedule = nil, nil
 }
 
 // Mutate makes random changes to this candidate.
 func (s *candidate) Mutate(rng *rand.Rand) {
-	// TODO: Test to see if more mutations than 1 should be done.
-	eaopt.MutPermuteInt(s.order, 1, rng)
+	eaopt.MutPermuteInt(s.order, s.scheduler.swaps, rng)
+	s.schedule = nil
 }
 
 // Evaluate evaluates how good a candidate performs. Lower is better.
 func (s *candidate) Evaluate() (float64, error) {
 	r, err := s.Schedule()
-	return r.Evaluate(), err
+	if err != nil {
+		return 0, err
+	}
+	s.schedule = &r
+	return r.Evaluate(), nil
 }
 
 type attendeeEvents struct {
 	Attendee Attendee
-	// Non-overlapping scheduled events. At least one element, always.
+	// Non-overlapping scheduled events sorted by start time. At least one
+	// element, always.
 	Scheduled []ScheduledEvent
 }
 
@@ -259,12 +588,30 @@ type attendeeEvents struct {
 type constructedSchedule struct {
 	// ScheduledEvent is a list of all events with actual times.
 	Events []ScheduledEvent
+	// Unscheduled is a list of all requests that couldn't be scheduled.
+	Unscheduled []UnscheduledRequest
+	// Skipped is a list of all occurrences of recurring requests that were
+	// skipped.
+	Skipped []SkippedOccurrence
 	// earliest time is that same as Scheduler.earliest.
 	earliest time.Time
+	// latest time is that same as Scheduler.latest.
+	latest time.Time
+	// ctx is passed on to calendar lookups.
+	ctx context.Context
+	// cost is the same as Scheduler.cost. DefaultCost is used if nil.
+	cost CostFunction
+	// buffer is the same as Scheduler.buffer.
+	buffer time.Duration
+	// travel is the same as Scheduler.travel.
+	travel TravelMatrix
 	// eventsByAttendee contains `ScheduledEvent`s grouped by attendee. It's
 	// used as a lookup table to more quickly be able to evaluate how well the
 	// solution performs.
 	eventsByAttendee map[AttendeeID]*attendeeEvents
+	// placed contains the time of the meeting of every request that has been
+	// placed.
+	placed map[*ScheduleRequest]TimeInterval
 }
 
 // MaxIterations is the number of iterations we allow before we consider we are
@@ -272,57 +619,205 @@ type constructedSchedule struct {
 // This avoids deadlock.
 const MaxIterations = 1000
 
+// unschedulableError is returned by constructedSchedule.Add when a request
+// can't be scheduled.
+type unschedulableError struct {
+	reason UnscheduledReason
+	err    error
+	// blocker is what pushed the request past its deadline or latest start,
+	// if anything did.
+	blocker UnscheduledReason
+}
+
+func (e *unschedulableError) Error() string {
+	if e.err != nil {
+		return string(e.reason) + ": " + e.err.Error()
+	}
+	return string(e.reason)
+}
+
+// deadline returns the time at which req must have ended. It's zero if there
+// is no such time.
+func (c *constructedSchedule) deadline(req *ScheduleRequest) time.Time {
+	deadline := c.latest
+	if !req.Deadline.IsZero() && (deadline.IsZero() || req.Deadline.Before(deadline)) {
+		deadline = req.Deadline
+	}
+	return deadline
+}
+
 // Add schedules a single ScheduleRequest. It does so by starting on
 // constructedSchedule.earliest and moving forward until it find an empty slot.
+// An *unschedulableError is returned if no slot can be found.
 func (c *constructedSchedule) Add(req *ScheduleRequest) error {
+	if req.needsRoom() && req.Mode != RoomOptional && len(suitableRooms(req)) == 0 {
+		return &unschedulableError{reason: NoRoomAvailable}
+	}
+
+	start, latestStart := c.dependencyBounds(req)
+	start = latest(start, c.earliest, req.NotBefore)
+
+	var events []ScheduledEvent
+	var err error
+	switch {
+	case req.Recurrence != nil:
+		var skipped []SkippedOccurrence
+		events, skipped, err = c.placeSeries(req, start, latestStart)
+		c.Skipped = append(c.Skipped, skipped...)
+	case req.Split != nil:
+		events, err = c.placeSessions(req, start, latestStart)
+	default:
+		var se ScheduledEvent
+		se, err = c.place(req, req.Length, start, latestStart, c.deadline(req))
+		events = []ScheduledEvent{se}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, se := range events {
+		c.commit(se)
+	}
+	c.placed[req] = TimeInterval{events[0].Start, events[len(events)-1].End}
+	return nil
+}
+
+// place finds the earliest time at or after start at which a meeting of length
+// for req can take place. The meeting must start at latestStart at the latest
+// and end at deadline at the latest, unless they are zero. An
+// *unschedulableError is returned if no slot can be found.
+func (c *constructedSchedule) place(req *ScheduleRequest, length time.Duration, start, latestStart, deadline time.Time) (ScheduledEvent, error) {
 	candidate := ScheduledEvent{
 		TimeInterval: TimeInterval{
-			c.earliest,
-			c.earliest.Add(req.Length),
+			start,
+			start.Add(length),
 		},
 		Attendees: req.Attendees,
 		Request:   req,
 	}
 
+	// blocker is what most recently prevented the request from being placed.
+	// It's the reason reported if we give up.
+	blocker := NoCommonFreeTime
+
+	// pooled are the members picked from the pools of the request.
+	var pooled []Attendee
+
 	iterations := 0
 	for {
+		iterations++
+		if iterations > MaxIterations {
+			return ScheduledEvent{}, &unschedulableError{reason: blocker}
+		}
+		if !deadline.IsZero() && candidate.End.After(deadline) {
+			return ScheduledEvent{}, &unschedulableError{reason: ExceededHorizon, blocker: blocker}
+		}
+		if !latestStart.IsZero() && candidate.Start.After(latestStart) {
+			return ScheduledEvent{}, &unschedulableError{reason: ExceededHorizon, blocker: blocker}
+		}
+
+		next, ok := c.findWorkingHoursStart(candidate)
+		if !ok {
+			return ScheduledEvent{}, &unschedulableError{reason: NoCommonFreeTime}
+		}
+		if next.After(candidate.Start) {
+			blocker = NoCommonFreeTime
+			candidate.Start = next
+			candidate.End = candidate.Start.Add(length)
+			continue
+		}
+
+		if next, conflicts := c.findFocusTimeConflict(candidate); conflicts {
+			blocker = NoCommonFreeTime
+			candidate.Start = next
+			candidate.End = candidate.Start.Add(length)
+			continue
+		}
+
+		if next, conflicts := c.findLimitConflict(candidate); conflicts {
+			blocker = NoCommonFreeTime
+			candidate.Start = next
+			candidate.End = candidate.Start.Add(length)
+			continue
+		}
+
 		// TODO: Attendee already has meeting better name?
 		overlap, overlaps, err := c.findAttendeeOverlap(candidate)
 		if err != nil {
-			return err
+			return ScheduledEvent{}, c.calendarError(err)
 		}
 		if overlaps {
+			blocker = NoCommonFreeTime
 			candidate.Start = overlap.End
-			candidate.End = candidate.Start.Add(req.Length)
+			candidate.End = candidate.Start.Add(length)
 			continue
 		}
 
-		// TODO: Investigate if we can do better room allocation. For example,
-		// cost for switching room or cost for using a large room with few
-		// people.
+		members, next, ok, err := c.findQuorum(candidate)
+		if err != nil {
+			return ScheduledEvent{}, err
+		}
+		if !ok {
+			if next.IsZero() {
+				return ScheduledEvent{}, &unschedulableError{reason: NoCommonFreeTime}
+			}
+			blocker = NoCommonFreeTime
+			candidate.Start = next
+			candidate.End = candidate.Start.Add(length)
+			continue
+		}
+		pooled = members
+
+		if !req.needsRoom() {
+			break
+		}
+
+		// Travel times depend on the room, so the pool members are picked
+		// again for every room.
 		busyRooms, nextTimeToTry := c.findAlreadyScheduledRooms(candidate.TimeInterval)
-		room, found, err := c.findAvailableRoom(candidate, busyRooms)
+		room, members, nextFreeRoom, err := c.findAvailableRoom(candidate, busyRooms)
 		if err != nil {
-			return err
+			return ScheduledEvent{}, c.calendarError(err)
 		}
-		if found {
+		if room != nil {
 			candidate.Room = *room
+			pooled = members
+			break
+		}
+		if req.Mode == RoomOptional {
+			// Rather meet over video than wait for a room.
 			break
-		} else {
-			candidate.Start = *nextTimeToTry
-			candidate.End = candidate.Start.Add(req.Length)
 		}
 
-		iterations++
-		if iterations > MaxIterations {
-			return errors.New("too many iterations")
+		blocker = NoRoomAvailable
+		if nextTimeToTry == nil || (nextFreeRoom != nil && nextFreeRoom.Before(*nextTimeToTry)) {
+			nextTimeToTry = nextFreeRoom
+		}
+		if nextTimeToTry == nil {
+			return ScheduledEvent{}, &unschedulableError{reason: NoRoomAvailable}
 		}
+		candidate.Start = *nextTimeToTry
+		candidate.End = candidate.Start.Add(length)
 	}
 
 	// We have found a time that works.
 
-	c.Events = append(c.Events, candidate)
-	for _, a := range req.Attendees {
+	available, unavailable, err := c.findAvailableOptional(candidate)
+	if err != nil {
+		return ScheduledEvent{}, err
+	}
+	attending := append(append([]Attendee(nil), req.Attendees...), pooled...)
+	available, overflow := admitOptional(candidate.Room, attending, available)
+	candidate.Attendees = append(attending, available...)
+	candidate.UnavailableOptional = append(unavailable, overflow...)
+
+	return candidate, nil
+}
+
+// commit adds se to the schedule.
+func (c *constructedSchedule) commit(se ScheduledEvent) {
+	c.Events = append(c.Events, se)
+	for _, a := range se.Attendees {
 		e, exists := c.eventsByAttendee[a.ID]
 		if !exists {
 			e = &attendeeEvents{
@@ -330,15 +825,107 @@ func (c *constructedSchedule) Add(req *ScheduleRequest) error {
 			}
 			c.eventsByAttendee[a.ID] = e
 		}
-		e.Scheduled = append(e.Scheduled, candidate)
+		// Keep the events sorted since a request can be placed in a gap
+		// before previously scheduled events.
+		i := sort.Search(len(e.Scheduled), func(i int) bool {
+			return e.Scheduled[i].Start.After(se.Start)
+		})
+		e.Scheduled = append(e.Scheduled, ScheduledEvent{})
+		copy(e.Scheduled[i+1:], e.Scheduled[i:])
+		e.Scheduled[i] = se
 	}
+}
 
-	return nil
+// rollback removes events, which must be the most recently committed events,
+// from the schedule. It undoes commit.
+func (c *constructedSchedule) rollback(events []ScheduledEvent) {
+	c.Events = c.Events[:len(c.Events)-len(events)]
+	for _, se := range events {
+		for _, a := range se.Attendees {
+			e := c.eventsByAttendee[a.ID]
+			for i, scheduled := range e.Scheduled {
+				if scheduled.Request == se.Request && scheduled.Session == se.Session && scheduled.Occurrence == se.Occurrence {
+					e.Scheduled = append(e.Scheduled[:i], e.Scheduled[i+1:]...)
+					break
+				}
+			}
+			if len(e.Scheduled) == 0 {
+				delete(c.eventsByAttendee, a.ID)
+			}
+		}
+	}
+}
+
+// findAvailableOptional splits the optional attendees of se's request into the
+// ones that are available to attend se and the ones that aren't.
+func (c *constructedSchedule) findAvailableOptional(se ScheduledEvent) ([]Attendee, []Attendee, error) {
+	var available, unavailable []Attendee
+	for _, a := range se.Request.OptionalAttendees {
+		free, _, err := c.findAttendeeAvailability(a, se)
+		if err != nil {
+			return nil, nil, err
+		}
+		if free {
+			available = append(available, a)
+		} else {
+			unavailable = append(unavailable, a)
+		}
+	}
+	return available, unavailable, nil
+}
+
+// findAttendeeAvailability checks if attendee a, on their own, is able to
+// attend se, including travelling to and from the room of se. If not, it
+// returns the next time to try, which is zero if a never will be. An attendee
+// with a failing calendar is treated as unavailable rather than making the
+// request unschedulable.
+func (c *constructedSchedule) findAttendeeAvailability(a Attendee, se ScheduledEvent) (bool, time.Time, error) {
+	single := se
+	single.Attendees = []Attendee{a}
+
+	next, ok := c.findWorkingHoursStart(single)
+	if !ok {
+		return false, time.Time{}, nil
+	}
+	if next.After(se.Start) {
+		return false, next, nil
+	}
+	if next, conflicts := c.findFocusTimeConflict(single); conflicts {
+		return false, next, nil
+	}
+	if next, conflicts := c.findLimitConflict(single); conflicts {
+		return false, next, nil
+	}
+	overlap, overlaps, err := c.findAttendeeOverlap(single)
+	if err != nil {
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			return false, time.Time{}, ctxErr
+		}
+		return false, time.Time{}, nil
+	}
+	if overlaps {
+		return false, overlap.End, nil
+	}
+	if next, conflicts := c.findTravelConflict(single, se.Room); conflicts {
+		return false, next, nil
+	}
+	return true, time.Time{}, nil
+}
+
+// calendarError wraps an error from a calendar lookup. A failing calendar
+// only makes the request unschedulable while a done context aborts the whole
+// schedule.
+func (c *constructedSchedule) calendarError(err error) error {
+	if ctxErr := c.ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return &unschedulableError{reason: CalendarError, err: err}
 }
 
 // findAlreadyScheduledRooms returns a list of rooms that are already scheduled
-// over time interval ti. It also returns the earliest end timestamp for a busy
-// room which is used to know next time we should try to reschedule.
+// over time interval ti, including their buffers. It also returns the earliest
+// end timestamp for a busy room which is used to know next time we should try
+// to reschedule.
 func (c *constructedSchedule) findAlreadyScheduledRooms(ti TimeInterval) ([]Room, *time.Time) {
 	m := make(map[RoomID]Room)
 	var earliestEnd *time.Time
@@ -346,9 +933,16 @@ func (c *constructedSchedule) findAlreadyScheduledRooms(ti TimeInterval) ([]Room
 		// TODO: This loop can be optimized. We could iterate from the end and
 		// once we are seeing events that end before ti we can stop iterating.
 
-		if event.TimeInterval.Overlaps(ti) {
-			if earliestEnd == nil || event.End.Before(*earliestEnd) {
-				earliestEnd = &event.End
+		if event.Room.ID == "" {
+			// Over video only.
+			continue
+		}
+
+		occupied := event.TimeInterval.padded(event.Room.Buffer)
+		if occupied.Overlaps(ti) {
+			if earliestEnd == nil || occupied.End.Before(*earliestEnd) {
+				end := occupied.End
+				earliestEnd = &end
 			}
 			m[event.Room.ID] = event.Room
 		}
@@ -361,43 +955,115 @@ func (c *constructedSchedule) findAlreadyScheduledRooms(ti TimeInterval) ([]Room
 	return rooms, earliestEnd
 }
 
-// findAvailableRoom returns the first available room it finds which isn't being
-// used over time interval ti, and isn't part of excluded rooms.
-func (c *constructedSchedule) findAvailableRoom(se ScheduledEvent, excluded []Room) (*Room, bool, error) {
+// findAvailableRoom returns the smallest available room it finds which is
+// suitable, isn't being used over time interval ti, leaves the attendees of se
+// enough time to travel to and from it, and isn't part of excluded rooms. It
+// also returns the pool members picked among those that can travel to the
+// room. If no room is available it returns the earliest time at which a room's
+// calendar event or an attendee's travel ends, if known.
+func (c *constructedSchedule) findAvailableRoom(se ScheduledEvent, excluded []Room) (*Room, []Attendee, *time.Time, error) {
 	lookup := make(map[RoomID]struct{})
 	for _, r := range excluded {
 		lookup[r.ID] = struct{}{}
 	}
 
-	for _, room := range se.Request.PossibleRooms {
+	var earliestEnd *time.Time
+	for _, room := range suitableRooms(se.Request) {
 		if _, ignored := lookup[room.ID]; ignored {
 			continue
 		}
 
-		_, overlaps, err := room.Calendar.Overlap(se.TimeInterval)
+		if next, conflicts := c.findTravelConflict(se, room); conflicts {
+			if earliestEnd == nil || next.Before(*earliestEnd) {
+				earliestEnd = &next
+			}
+			continue
+		}
+
+		ev, overlaps, err := overlap(c.ctx, room.Calendar, se.TimeInterval.padded(room.Buffer))
 		if err != nil {
-			return nil, false, err
+			return nil, nil, nil, err
 		}
-		if !overlaps {
-			return &room, true, nil
+		if overlaps {
+			if ev != nil && (earliestEnd == nil || ev.End.Add(room.Buffer).Before(*earliestEnd)) {
+				end := ev.End.Add(room.Buffer)
+				earliestEnd = &end
+			}
+			continue
+		}
+
+		// Members that can't make it to this room in time may be replaced
+		// by others that can.
+		inRoom := se
+		inRoom.Room = room
+		members, next, ok, err := c.findQuorum(inRoom)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if ok {
+			return &room, members, nil, nil
+		}
+		if !next.IsZero() && (earliestEnd == nil || next.Before(*earliestEnd)) {
+			earliestEnd = &next
 		}
 	}
 
-	return nil, false, nil
+	return nil, nil, earliestEnd, nil
 }
 
-// findAttendeeOverlap finds the attendees which are busy during the proposed time interval.
+// findWorkingHoursStart returns the earliest time at or after se.Start at which
+// all of se's attendees are working. It returns false if the attendees never
+// are working at the same time long enough to attend se.
+func (c *constructedSchedule) findWorkingHoursStart(se ScheduledEvent) (time.Time, bool) {
+	length := se.End.Sub(se.Start)
+	next := se.Start
+	for {
+		moved := false
+		for _, a := range se.Attendees {
+			if a.WorkingHours == nil {
+				continue
+			}
+			start, ok := a.WorkingHours.nextFit(TimeInterval{next, next.Add(length)}, c.workingHoursLocation(a))
+			if !ok {
+				return time.Time{}, false
+			}
+			if start.After(next) {
+				next = start
+				moved = true
+			}
+		}
+		if !moved {
+			return next, true
+		}
+
+		// Working hours repeat every week. If there is no common time
+		// within a week there never will be.
+		if next.Sub(se.Start) > 8*24*time.Hour {
+			return time.Time{}, false
+		}
+	}
+}
+
+// findAttendeeOverlap finds the attendees which are busy during the proposed
+// time interval, including their buffers. The returned event is extended by
+// the buffer.
 func (c *constructedSchedule) findAttendeeOverlap(se ScheduledEvent) (*CalendarEvent, bool, error) {
 
 	// Now we check if the user already has a meeting.
 
 	for _, a := range se.Attendees {
-		ev, overlaps, err := a.Calendar.Overlap(se.TimeInterval)
+		buffer := c.buffer
+		if a.Buffer > buffer {
+			buffer = a.Buffer
+		}
+		padded := se.TimeInterval.padded(buffer)
+
+		ev, overlaps, err := overlap(c.ctx, a.Calendar, padded)
 		if err != nil {
 			return nil, false, err
 		}
 		if overlaps {
-			return ev, true, nil
+			return &CalendarEvent{TimeInterval{ev.Start, ev.End.Add(buffer)}}, true, nil
 		}
 
 		if _, exist := c.eventsByAttendee[a.ID]; exist {
@@ -406,8 +1072,8 @@ func (c *constructedSchedule) findAttendeeOverlap(se ScheduledEvent) (*CalendarE
 				// end and once we are seeing events where
 				// scheduled.End.Before(se.Start) we can stop iterating.
 
-				if scheduled.TimeInterval.Overlaps(se.TimeInterval) {
-					return &CalendarEvent{scheduled.TimeInterval}, true, nil
+				if scheduled.TimeInterval.Overlaps(padded) {
+					return &CalendarEvent{TimeInterval{scheduled.Start, scheduled.End.Add(buffer)}}, true, nil
 				}
 			}
 		}
@@ -415,6 +1081,44 @@ func (c *constructedSchedule) findAttendeeOverlap(se ScheduledEvent) (*CalendarE
 	return nil, false, nil
 }
 
+// location returns the time zone in which attendee a lives.
+func (c *constructedSchedule) location(a Attendee) *time.Location {
+	if a.Location != nil {
+		return a.Location
+	}
+	if a.WorkingHours != nil && a.WorkingHours.Location != nil {
+		return a.WorkingHours.Location
+	}
+	return c.earliest.Location()
+}
+
+// workingHoursLocation returns the time zone in which the working hours of
+// attendee a are expressed.
+func (c *constructedSchedule) workingHoursLocation(a Attendee) *time.Location {
+	if a.WorkingHours != nil && a.WorkingHours.Location != nil {
+		return a.WorkingHours.Location
+	}
+	return c.location(a)
+}
+
+// sameLocalDay checks if a and b are on the same calendar day in loc.
+func sameLocalDay(a, b time.Time, loc *time.Location) bool {
+	ay, am, ad := a.In(loc).Date()
+	by, bm, bd := b.In(loc).Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// localSince returns the wall clock time that has passed in loc between from
+// and to. Contrary to to.Sub(from) it isn't affected by daylight saving time
+// transitions.
+func localSince(from, to time.Time, loc *time.Location) time.Duration {
+	wallClock := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+	return wallClock(to).Sub(wallClock(from))
+}
+
 // latest returns the latest time among a set of times.
 func latest(a time.Time, others ...time.Time) time.Time {
 	for _, b := range others {
@@ -425,36 +1129,89 @@ func latest(a time.Time, others ...time.Time) time.Time {
 	return a
 }
 
-// Evaluate evaluates how good a constructedSchedule performs. Attendees that
-// start their days late with meetings and/or attendees that have fragmented
-// days incur higher costs. That is, lower is better.
+// earliestOf returns the earliest time among a set of times.
+func earliestOf(a time.Time, others ...time.Time) time.Time {
+	for _, b := range others {
+		if b.Before(a) {
+			a = b
+		}
+	}
+	return a
+}
+
+// Evaluate evaluates how good a constructedSchedule performs using the cost
+// function of the scheduler. Requests that couldn't be scheduled and skipped
+// occurrences are penalized. Lower is better.
 func (c constructedSchedule) Evaluate() float64 {
-	var score time.Duration
-	for _, attendee := range c.eventsByAttendee {
-		// First event as early as possible.
-		score += attendee.Scheduled[0].Start.Sub(c.earliest)
+	cost := c.cost
+	if cost == nil {
+		cost = DefaultCost
+	}
+	score := cost.Cost(ScheduleView{&c})
 
-		// All events packed as tight as possible.
-		for i, nextEvent := range attendee.Scheduled[1:] {
-			curEvent := attendee.Scheduled[i]
-			score += nextEvent.Start.Sub(curEvent.End)
-		}
+	for _, u := range c.Unscheduled {
+		score += u.Request.priority() * float64(c.unscheduledCost(u.Request))
 	}
+	for _, skipped := range c.Skipped {
+		score += skipped.Request.priority() * float64(skipped.cost())
+	}
+	return score
+}
 
-	// TODO: Convert to seconds to not work with giant numbers?
-	return float64(score)
+// unscheduledCost is the cost of not scheduling req at all, before weighting it
+// by the priority of req. It's higher than the cost of scheduling it at its
+// deadline to make sure that the genetic algorithm doesn't drop requests to
+// get a cheaper schedule.
+func (c constructedSchedule) unscheduledCost(req *ScheduleRequest) time.Duration {
+	bound := c.deadline(req)
+	if bound.IsZero() {
+		// Without a deadline, the request could at worst have been scheduled
+		// after all other events.
+		bound = c.earliest
+		for _, event := range c.Events {
+			bound = latest(bound, event.End)
+		}
+		bound = bound.Add(req.Length)
+	}
+	perAttendee := bound.Sub(c.earliest) + 24*time.Hour
+	return time.Duration(req.attendeeCount()) * perAttendee
 }
 
 // Schedule constructs a constructedSchedule from a candidate. It does this by
 // laying out each ScheduleRequest one by one on each attendees "virtual
-// calendar".
+// calendar". Requests that can't be scheduled are recorded as unscheduled
+// instead of failing the whole schedule.
 func (s *candidate) Schedule() (constructedSchedule, error) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	sch := constructedSchedule{
-		earliest:         s.earliest,
+		earliest:         s.scheduler.earliest,
+		latest:           s.scheduler.latest,
+		ctx:              ctx,
+		cost:             s.scheduler.cost,
+		buffer:           s.scheduler.buffer,
+		travel:           s.scheduler.travel,
 		eventsByAttendee: make(map[AttendeeID]*attendeeEvents),
+		placed:           make(map[*ScheduleRequest]TimeInterval),
 	}
-	for _, event := range s.order {
-		if err := sch.Add(s.reqs[event]); err != nil {
+	order, circular := s.scheduler.layout(s.order)
+	for _, event := range circular {
+		sch.Unscheduled = append(sch.Unscheduled, UnscheduledRequest{s.scheduler.reqs[event], DependencyNotScheduled, nil})
+	}
+	for _, event := range order {
+		req := s.scheduler.reqs[event]
+		if !sch.dependenciesPlaced(s.scheduler.dependencies[event], s.scheduler.reqs) {
+			sch.Unscheduled = append(sch.Unscheduled, UnscheduledRequest{req, DependencyNotScheduled, nil})
+			continue
+		}
+		err := sch.Add(req)
+		if u, ok := err.(*unschedulableError); ok {
+			sch.Unscheduled = append(sch.Unscheduled, UnscheduledRequest{req, u.reason, u.err})
+			continue
+		}
+		if err != nil {
 			return sch, err
 		}
 	}
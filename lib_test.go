@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -27,14 +29,14 @@ func TestTimeIntervalOverlap(t *testing.T) {
 func TestOptimalSolutionEvaluation(t *testing.T) {
 	emptyCalendar := FakeCalendar{}
 	rooms := []Room{
-		{"room-1", emptyCalendar},
+		{ID: "room-1", Calendar: emptyCalendar},
 	}
 	attendees := []Attendee{
-		{"christian", emptyCalendar},
-		{"jens", emptyCalendar},
+		{ID: "christian", Calendar: emptyCalendar},
+		{ID: "jens", Calendar: emptyCalendar},
 	}
 	reqs := []*ScheduleRequest{
-		{60 * time.Minute, attendees, rooms},
+		{Length: 60 * time.Minute, Attendees: attendees, PossibleRooms: rooms},
 	}
 
 	// Monday morning at 9.
@@ -44,10 +46,11 @@ func TestOptimalSolutionEvaluation(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	events, err := scheduler.Run()
+	result, err := scheduler.Run()
 	if err != nil {
 		t.Fatal(err)
 	}
+	events := result.Events
 
 	if len(events) != 1 {
 		t.Error("Expected a single event scheduled:", events)
@@ -63,14 +66,14 @@ func TestOptimalSolutionEvaluation(t *testing.T) {
 func TestPuttingEventsEarlierInTheWeekIsBetter(t *testing.T) {
 	emptyCalendar := FakeCalendar{}
 	rooms := []Room{
-		{"room-1", emptyCalendar},
+		{ID: "room-1", Calendar: emptyCalendar},
 	}
-	attendee1 := Attendee{"christian", emptyCalendar}
-	attendee2 := Attendee{"jens", emptyCalendar}
-	attendee3 := Attendee{"henrik", emptyCalendar}
+	attendee1 := Attendee{ID: "christian", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "jens", Calendar: emptyCalendar}
+	attendee3 := Attendee{ID: "henrik", Calendar: emptyCalendar}
 	reqs := []*ScheduleRequest{
-		{60 * time.Minute, []Attendee{attendee1, attendee2}, rooms},
-		{30 * time.Minute, []Attendee{attendee1, attendee2, attendee3}, rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1, attendee2, attendee3}, PossibleRooms: rooms},
 	}
 
 	// Monday morning at 9.
@@ -80,10 +83,11 @@ func TestPuttingEventsEarlierInTheWeekIsBetter(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	events, err := scheduler.Run()
+	result, err := scheduler.Run()
 	if err != nil {
 		t.Fatal(err)
 	}
+	events := result.Events
 
 	if len(events) != 2 {
 		t.Error("Expected a single event scheduled:", events)
@@ -104,24 +108,28 @@ func TestPuttingEventsEarlierInTheWeekIsBetter(t *testing.T) {
 func TestFragmentedDayIsWorseThanNonFragmentedDay(t *testing.T) {
 	emptyCalendar := FakeCalendar{}
 	rooms := []Room{
-		{"room-1", emptyCalendar},
+		{ID: "room-1", Calendar: emptyCalendar},
 	}
-	attendee1 := Attendee{"a", emptyCalendar}
-	attendee2 := Attendee{"b", emptyCalendar}
-	attendee3 := Attendee{"c", emptyCalendar}
-	attendee4 := Attendee{"d", emptyCalendar}
-	attendee5 := Attendee{"e", emptyCalendar}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	attendee3 := Attendee{ID: "c", Calendar: emptyCalendar}
+	attendee4 := Attendee{ID: "d", Calendar: emptyCalendar}
+	attendee5 := Attendee{ID: "e", Calendar: emptyCalendar}
 	reqs := []*ScheduleRequest{
-		{60 * time.Minute, []Attendee{attendee1, attendee2}, rooms},
-		{60 * time.Minute, []Attendee{attendee5, attendee1}, rooms},
-		{60 * time.Minute, []Attendee{attendee3, attendee4}, rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee5, attendee1}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee3, attendee4}, PossibleRooms: rooms},
 	}
 
 	// Monday morning at 9.
 	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
 
-	better := candidate{now, reqs, []int{0, 1, 2}}
-	worse := candidate{now, reqs, []int{0, 2, 1}}
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	better := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	worse := candidate{scheduler: scheduler, order: []int{0, 2, 1}}
 
 	betterSchedule, err := better.Schedule()
 	if err != nil {
@@ -140,26 +148,29 @@ func TestFragmentedDayIsWorseThanNonFragmentedDay(t *testing.T) {
 func TestSchedulingOfSolution(t *testing.T) {
 	emptyCalendar := FakeCalendar{}
 	rooms := []Room{
-		{"room-1", emptyCalendar},
+		{ID: "room-1", Calendar: emptyCalendar},
 	}
-	attendee1 := Attendee{"a", emptyCalendar}
-	attendee2 := Attendee{"b", emptyCalendar}
-	attendee3 := Attendee{"c", emptyCalendar}
-	attendee4 := Attendee{"d", emptyCalendar}
-	attendee5 := Attendee{"e", emptyCalendar}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	attendee3 := Attendee{ID: "c", Calendar: emptyCalendar}
+	attendee4 := Attendee{ID: "d", Calendar: emptyCalendar}
+	attendee5 := Attendee{ID: "e", Calendar: emptyCalendar}
 	reqs := []*ScheduleRequest{
-		{15 * time.Minute, []Attendee{attendee1, attendee2}, rooms},
-		{60 * time.Minute, []Attendee{attendee5, attendee1}, rooms},
-		{30 * time.Minute, []Attendee{attendee3, attendee4}, rooms},
+		{Length: 15 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee5, attendee1}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee3, attendee4}, PossibleRooms: rooms},
 	}
 
 	// Monday morning at 9.
 	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
 
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
 	sol := candidate{
-		now,
-		reqs,
-		[]int{0, 1, 2},
+		scheduler: scheduler,
+		order:     []int{0, 1, 2},
 	}
 
 	schedule, err := sol.Schedule()
@@ -192,17 +203,17 @@ func TestSchedulingOfSolution(t *testing.T) {
 func TestDayFragmentationIsBad(t *testing.T) {
 	emptyCalendar := FakeCalendar{}
 	rooms := []Room{
-		{"room-1", emptyCalendar},
+		{ID: "room-1", Calendar: emptyCalendar},
 	}
-	attendee1 := Attendee{"a", emptyCalendar}
-	attendee2 := Attendee{"b", emptyCalendar}
-	attendee3 := Attendee{"c", emptyCalendar}
-	attendee4 := Attendee{"d", emptyCalendar}
-	attendee5 := Attendee{"e", emptyCalendar}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	attendee3 := Attendee{ID: "c", Calendar: emptyCalendar}
+	attendee4 := Attendee{ID: "d", Calendar: emptyCalendar}
+	attendee5 := Attendee{ID: "e", Calendar: emptyCalendar}
 	reqs := []*ScheduleRequest{
-		{60 * time.Minute, []Attendee{attendee1, attendee2}, rooms},
-		{60 * time.Minute, []Attendee{attendee3, attendee4}, rooms},
-		{60 * time.Minute, []Attendee{attendee5, attendee1}, rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee3, attendee4}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee5, attendee1}, PossibleRooms: rooms},
 	}
 
 	// Monday morning at 9.
@@ -212,10 +223,11 @@ func TestDayFragmentationIsBad(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	events, err := scheduler.Run()
+	result, err := scheduler.Run()
 	if err != nil {
 		t.Fatal(err)
 	}
+	events := result.Events
 
 	if len(events) != 3 {
 		t.Error("Expected a single event scheduled:", events)
@@ -235,6 +247,443 @@ func TestDayFragmentationIsBad(t *testing.T) {
 	}
 }
 
+func TestSchedulingInOverlapOfLocalWorkingHours(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar, Location: stockholm, WorkingHours: WeekdayWorkingHours(nil, 9*time.Hour, 17*time.Hour)}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar, Location: newYork, WorkingHours: WeekdayWorkingHours(nil, 9*time.Hour, 17*time.Hour)}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9 in Stockholm.
+	now, _ := time.ParseInLocation("02-01-2006 15:04", "02-12-2019 09:00", stockholm)
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 9 in New York is 15 in Stockholm. Only 15-17 overlaps.
+	expected, _ := time.ParseInLocation("02-01-2006 15:04", "02-12-2019 15:00", stockholm)
+	for i, event := range schedule.Events {
+		if !event.Start.Equal(expected) {
+			t.Errorf("Unexpected start of event %d. Expected: %s Was: %s", i, expected, event.Start)
+		}
+		expected = expected.Add(reqs[i].Length)
+	}
+}
+
+func TestEvaluationUsesLocalDays(t *testing.T) {
+	bangalore, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar, Location: bangalore}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+	}
+	earliest, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	schedule := func(gap time.Duration) constructedSchedule {
+		events := &attendeeEvents{Attendee: attendee}
+		for i, req := range reqs {
+			start := earliest.Add(time.Duration(i) * (req.Length + gap))
+			events.Scheduled = append(events.Scheduled, ScheduledEvent{
+				TimeInterval: TimeInterval{start, start.Add(req.Length)},
+				Request:      req,
+			})
+		}
+		return constructedSchedule{
+			earliest:         earliest,
+			eventsByAttendee: map[AttendeeID]*attendeeEvents{attendee.ID: events},
+		}
+	}
+
+	// 09:00 UTC is 14:30 in Bangalore. A 9 hour gap puts the second meeting
+	// on the next local day.
+	sameDay, nextDay := schedule(8*time.Hour), schedule(9*time.Hour)
+	if c, expected := FragmentationCost.Cost(ScheduleView{&sameDay}), float64(8*time.Hour); c != expected {
+		t.Error("Unexpected fragmentation on the same day. Expected:", expected, "Was:", c)
+	}
+	if c := FragmentationCost.Cost(ScheduleView{&nextDay}); c != 0 {
+		t.Error("Expected no fragmentation over night. Was:", c)
+	}
+	if c, expected := EarlinessCost.Cost(ScheduleView{&nextDay}), float64(10*time.Hour); c != expected {
+		t.Error("Unexpected earliness. Expected:", expected, "Was:", c)
+	}
+}
+
+func TestRequestsNotFittingInHorizonAreUnscheduled(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+
+	emptyCalendar := FakeCalendar{}
+	busyCalendar := FakeCalendar{{now, now.Add(8 * time.Hour)}}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: busyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms, Deadline: now.Add(30 * time.Minute)},
+	}
+
+	scheduler, err := New(now, reqs, Latest(now.Add(4*time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scheduler.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Events) != 1 || result.Events[0].Request != reqs[0] {
+		t.Errorf("Expected only the first request to be scheduled. Events:\n%s", pp.Sprint(result.Events))
+	}
+	if len(result.Unscheduled) != 2 {
+		t.Fatalf("Expected two unscheduled requests. Unscheduled:\n%s", pp.Sprint(result.Unscheduled))
+	}
+	for _, u := range result.Unscheduled {
+		if u.Request == reqs[0] {
+			t.Error("Expected the first request to be scheduled.")
+		}
+		if u.Reason != ExceededHorizon {
+			t.Error("Unexpected reason. Expected:", ExceededHorizon, "Was:", u.Reason)
+		}
+	}
+}
+
+func TestUnschedulableRequestsDoNotFailTheRun(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	mondays := &WorkingHours{Windows: []WeeklyWindow{{time.Monday, 9 * time.Hour, 17 * time.Hour}}}
+	tuesdays := &WorkingHours{Windows: []WeeklyWindow{{time.Tuesday, 9 * time.Hour, 17 * time.Hour}}}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar, WorkingHours: mondays}
+	attendee3 := Attendee{ID: "c", Calendar: emptyCalendar, WorkingHours: tuesdays}
+	attendee4 := Attendee{ID: "d", Calendar: ErrorCalendar{}}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee2, attendee3}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee4}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scheduler.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Events) != 1 || result.Events[0].Request != reqs[0] {
+		t.Errorf("Expected only the first request to be scheduled. Events:\n%s", pp.Sprint(result.Events))
+	}
+	expected := map[*ScheduleRequest]UnscheduledReason{
+		reqs[1]: NoRoomAvailable,
+		reqs[2]: NoCommonFreeTime,
+		reqs[3]: CalendarError,
+	}
+	if len(result.Unscheduled) != len(expected) {
+		t.Fatalf("Unexpected unscheduled requests:\n%s", pp.Sprint(result.Unscheduled))
+	}
+	for _, u := range result.Unscheduled {
+		if u.Reason != expected[u.Request] {
+			t.Error("Unexpected reason. Expected:", expected[u.Request], "Was:", u.Reason)
+		}
+		if (u.Err != nil) != (u.Reason == CalendarError) {
+			t.Error("Expected an error only for calendar errors. Was:", u.Err)
+		}
+	}
+}
+
+func TestRunContextStopsWhenDone(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs, NGenerations(1<<31))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result, err := scheduler.RunContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Events) != len(reqs) {
+		t.Errorf("Expected all requests to be scheduled. Events:\n%s", pp.Sprint(result.Events))
+	}
+}
+
+func TestRunContextAlreadyCancelled(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{{ID: "a", Calendar: emptyCalendar}}, PossibleRooms: rooms},
+	}
+	scheduler, err := New(time.Now(), reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scheduler.RunContext(ctx); err != context.Canceled {
+		t.Error("Expected context.Canceled. Was:", err)
+	}
+}
+
+func TestContextIsPassedToCalendars(t *testing.T) {
+	calendar := &ContextFakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: FakeCalendar{}},
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{{ID: "a", Calendar: calendar}}, PossibleRooms: rooms},
+	}
+	scheduler, err := New(time.Now(), reqs, NGenerations(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	if _, err := scheduler.RunContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if calendar.ctx == nil || calendar.ctx.Value(key{}) != "value" {
+		t.Error("Expected the context to be passed to the calendar.")
+	}
+}
+
+func TestRunsWithSameSeedAreReproducible(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+		{ID: "room-2", Calendar: emptyCalendar},
+	}
+	attendees := []Attendee{
+		{ID: "a", Calendar: emptyCalendar},
+		{ID: "b", Calendar: emptyCalendar},
+		{ID: "c", Calendar: emptyCalendar},
+		{ID: "d", Calendar: emptyCalendar},
+	}
+	var reqs []*ScheduleRequest
+	for i := range attendees {
+		for j := range attendees[:i] {
+			reqs = append(reqs, &ScheduleRequest{
+				Length:        time.Duration(15*(i+j+1)) * time.Minute,
+				Attendees:     []Attendee{attendees[i], attendees[j]},
+				PossibleRooms: rooms,
+			})
+		}
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	run := func(options ...Config) *Result {
+		scheduler, err := New(now, reqs, append(options, NGenerations(20))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := scheduler.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := run()
+	second := run(Seed(first.Seed))
+	if second.Seed != first.Seed {
+		t.Error("Expected the seed to be reported. Expected:", first.Seed, "Was:", second.Seed)
+	}
+	if len(first.Events) != len(second.Events) {
+		t.Fatal("Expected the same number of events.")
+	}
+	for i := range first.Events {
+		f, s := first.Events[i], second.Events[i]
+		if f.Request != s.Request || f.TimeInterval != s.TimeInterval || f.Room.ID != s.Room.ID {
+			t.Errorf("Event %d differs.\nFirst:\n%s\nSecond:\n%s", i, pp.Sprint(f), pp.Sprint(s))
+		}
+	}
+}
+
+func TestBuffersBetweenMeetings(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	busyCalendar := FakeCalendar{{now, now.Add(60 * time.Minute)}}
+
+	tests := []struct {
+		name      string
+		options   []Config
+		attendee1 Attendee
+		attendee2 Attendee
+		room      Room
+		expected  []time.Time
+	}{
+		{
+			"global buffer",
+			[]Config{Buffer(15 * time.Minute)},
+			Attendee{ID: "a", Calendar: emptyCalendar},
+			Attendee{ID: "a", Calendar: emptyCalendar},
+			Room{ID: "room-1", Calendar: emptyCalendar},
+			[]time.Time{now, now.Add(75 * time.Minute)},
+		},
+		{
+			"attendee buffer",
+			[]Config{Buffer(15 * time.Minute)},
+			Attendee{ID: "a", Calendar: emptyCalendar, Buffer: 30 * time.Minute},
+			Attendee{ID: "a", Calendar: emptyCalendar, Buffer: 30 * time.Minute},
+			Room{ID: "room-1", Calendar: emptyCalendar},
+			[]time.Time{now, now.Add(90 * time.Minute)},
+		},
+		{
+			"buffer after preexisting event",
+			[]Config{Buffer(15 * time.Minute)},
+			Attendee{ID: "a", Calendar: busyCalendar},
+			Attendee{ID: "a", Calendar: busyCalendar},
+			Room{ID: "room-1", Calendar: emptyCalendar},
+			[]time.Time{now.Add(75 * time.Minute), now.Add(150 * time.Minute)},
+		},
+		{
+			"room buffer",
+			nil,
+			Attendee{ID: "a", Calendar: emptyCalendar},
+			Attendee{ID: "b", Calendar: emptyCalendar},
+			Room{ID: "room-1", Calendar: emptyCalendar, Buffer: 10 * time.Minute},
+			[]time.Time{now, now.Add(70 * time.Minute)},
+		},
+		{
+			"room buffer after preexisting booking",
+			nil,
+			Attendee{ID: "a", Calendar: emptyCalendar},
+			Attendee{ID: "b", Calendar: emptyCalendar},
+			Room{ID: "room-1", Calendar: busyCalendar, Buffer: 10 * time.Minute},
+			[]time.Time{now.Add(70 * time.Minute), now.Add(140 * time.Minute)},
+		},
+	}
+	for _, test := range tests {
+		rooms := []Room{test.room}
+		reqs := []*ScheduleRequest{
+			{Length: 60 * time.Minute, Attendees: []Attendee{test.attendee1}, PossibleRooms: rooms},
+			{Length: 60 * time.Minute, Attendees: []Attendee{test.attendee2}, PossibleRooms: rooms},
+		}
+		scheduler, err := New(now, reqs, test.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+		schedule, err := sol.Schedule()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range test.expected {
+			if s := schedule.Events[i].Start; !s.Equal(e) {
+				t.Errorf("%s: Unexpected start of event %d. Expected: %s Was: %s", test.name, i, e, s)
+			}
+		}
+	}
+}
+
+func TestOptionalAttendees(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	busyCalendar := FakeCalendar{{now, now.Add(8 * time.Hour)}}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+		{ID: "room-2", Calendar: emptyCalendar},
+	}
+	required := Attendee{ID: "a", Calendar: emptyCalendar}
+	busy := Attendee{ID: "b", Calendar: busyCalendar}
+	free := Attendee{ID: "c", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{required}, OptionalAttendees: []Attendee{busy, free}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Attendees: []Attendee{free}, PossibleRooms: rooms},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := schedule.Events[0]
+	if !first.Start.Equal(now) {
+		t.Error("Expected a busy optional attendee to not block the meeting. Start:", first.Start)
+	}
+	if len(first.Attendees) != 2 || first.Attendees[0].ID != "a" || first.Attendees[1].ID != "c" {
+		t.Errorf("Unexpected attendees:\n%s", pp.Sprint(first.Attendees))
+	}
+	if len(first.UnavailableOptional) != 1 || first.UnavailableOptional[0].ID != "b" {
+		t.Errorf("Unexpected unavailable optional attendees:\n%s", pp.Sprint(first.UnavailableOptional))
+	}
+	if s, expected := schedule.Events[1].Start, now.Add(60*time.Minute); !s.Equal(expected) {
+		t.Error("Expected an attending optional attendee to be busy. Expected:", expected, "Was:", s)
+	}
+	if c, expected := OptionalAttendeeCost.Cost(ScheduleView{&schedule}), float64(60*time.Minute); c != expected {
+		t.Error("Unexpected cost. Expected:", expected, "Was:", c)
+	}
+}
+
+func TestLatestMustBeAfterEarliest(t *testing.T) {
+	now := time.Now()
+	if _, err := New(now, nil, Latest(now)); err == nil {
+		t.Error("Expected an error.")
+	}
+}
+
 func checkEvent(t *testing.T, event ScheduledEvent) {
 	if diff := event.End.Sub(event.Start); diff != event.Request.Length {
 		t.Error("Wrong event length. Expected:", event.Request.Length, "Was:", diff)
@@ -249,10 +698,26 @@ func (f FakeCalendar) Overlap(interval TimeInterval) (*CalendarEvent, bool, erro
 		if ti.End.Before(ti.Start) {
 			panic("incorrect time interval")
 		}
-		if !(ti.End.Before(interval.Start) || interval.Start.Before(ti.End)) {
+		if ti.Overlaps(interval) {
 			return &CalendarEvent{ti}, true, nil
 		}
 
 	}
 	return nil, false, nil
 }
+
+type ErrorCalendar struct{}
+
+func (e ErrorCalendar) Overlap(interval TimeInterval) (*CalendarEvent, bool, error) {
+	return nil, false, errors.New("calendar unavailable")
+}
+
+type ContextFakeCalendar struct {
+	FakeCalendar
+	ctx context.Context
+}
+
+func (c *ContextFakeCalendar) OverlapContext(ctx context.Context, interval TimeInterval) (*CalendarEvent, bool, error) {
+	c.ctx = ctx
+	return c.FakeCalendar.Overlap(interval)
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// MeetingLimits caps how much an attendee meets per day so that a packed day
+// doesn't become an exhausting one. Only meetings placed by the scheduler are
+// taken into account. Zero values mean no limit.
+type MeetingLimits struct {
+	// MaxPerDay is the maximum number of meetings per local day.
+	MaxPerDay int
+	// MaxTimePerDay is the maximum total length of meetings per local day. A
+	// single meeting longer than it can still be scheduled on a day without
+	// other meetings.
+	MaxTimePerDay time.Duration
+	// MaxConsecutive is the maximum time from the start of the first to the
+	// end of the last meeting in a row of meetings without a break. A single
+	// meeting longer than it can still be scheduled on its own.
+	MaxConsecutive time.Duration
+	// Break is the shortest gap between two meetings that counts as a break
+	// for MaxConsecutive. DefaultBreak is used if zero.
+	Break time.Duration
+}
+
+// DefaultBreak is the shortest gap between two meetings that counts as a
+// break if MeetingLimits.Break isn't set.
+const DefaultBreak = 15 * time.Minute
+
+// findLimitConflict checks if se would make one of its attendees exceed their
+// MeetingLimits. If it does, it returns the next time to try.
+func (c *constructedSchedule) findLimitConflict(se ScheduledEvent) (time.Time, bool) {
+	for _, a := range se.Attendees {
+		limits := a.Limits
+		if limits == nil {
+			continue
+		}
+		e, exists := c.eventsByAttendee[a.ID]
+		if !exists {
+			continue
+		}
+		loc := c.location(a)
+
+		count, total := 1, se.End.Sub(se.Start)
+		for _, scheduled := range e.Scheduled {
+			if sameLocalDay(scheduled.Start, se.Start, loc) {
+				count++
+				total += scheduled.End.Sub(scheduled.Start)
+			}
+		}
+		if (limits.MaxPerDay > 0 && count > limits.MaxPerDay) || (limits.MaxTimePerDay > 0 && count > 1 && total > limits.MaxTimePerDay) {
+			return localMidnight(se.Start, loc).AddDate(0, 0, 1), true
+		}
+
+		if limits.MaxConsecutive > 0 {
+			brk := limits.Break
+			if brk <= 0 {
+				brk = DefaultBreak
+			}
+			run, othersEnd, ok := consecutiveRun(e.Scheduled, se, brk)
+			if ok && run.End.Sub(run.Start) > limits.MaxConsecutive {
+				// Try again after a break following the other meetings.
+				return othersEnd.Add(brk), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// consecutiveRun returns the row of meetings without breaks of at least brk
+// between them that se would be part of if it was added to scheduled. It also
+// returns the latest end of the other meetings in the row and false if se
+// would be on its own.
+func consecutiveRun(scheduled []ScheduledEvent, se ScheduledEvent, brk time.Duration) (TimeInterval, time.Time, bool) {
+	run := se.TimeInterval
+	var othersEnd time.Time
+	included := make([]bool, len(scheduled))
+	for changed := true; changed; {
+		changed = false
+		for i, s := range scheduled {
+			if included[i] || !s.Start.Before(run.End.Add(brk)) || !run.Start.Before(s.End.Add(brk)) {
+				continue
+			}
+			included[i], changed = true, true
+			run = TimeInterval{earliestOf(run.Start, s.Start), latest(run.End, s.End)}
+			othersEnd = latest(othersEnd, s.End)
+		}
+	}
+	return run, othersEnd, !othersEnd.IsZero()
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeetingLimits(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	tuesday := now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name     string
+		limits   MeetingLimits
+		expected []time.Time
+	}{
+		{
+			"no limits",
+			MeetingLimits{},
+			[]time.Time{now, now.Add(1 * time.Hour), now.Add(2 * time.Hour)},
+		},
+		{
+			"max meetings per day",
+			MeetingLimits{MaxPerDay: 2},
+			[]time.Time{now, now.Add(1 * time.Hour), tuesday},
+		},
+		{
+			"max meeting time per day",
+			MeetingLimits{MaxTimePerDay: 150 * time.Minute},
+			[]time.Time{now, now.Add(1 * time.Hour), tuesday},
+		},
+		{
+			"max consecutive meeting time",
+			MeetingLimits{MaxConsecutive: 2 * time.Hour, Break: 30 * time.Minute},
+			[]time.Time{now, now.Add(1 * time.Hour), now.Add(150 * time.Minute)},
+		},
+	}
+	for _, test := range tests {
+		emptyCalendar := FakeCalendar{}
+		rooms := []Room{
+			{ID: "room-1", Calendar: emptyCalendar},
+		}
+		limits := test.limits
+		attendee := Attendee{
+			ID:           "a",
+			Calendar:     emptyCalendar,
+			WorkingHours: WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour),
+			Limits:       &limits,
+		}
+		reqs := []*ScheduleRequest{
+			{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+			{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+			{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms},
+		}
+
+		scheduler, err := New(now, reqs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+		schedule, err := sol.Schedule()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, e := range test.expected {
+			if s := schedule.Events[i].Start; !s.Equal(e) {
+				t.Errorf("%s: Unexpected start of event %d. Expected: %s Was: %s", test.name, i, e, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// TimePreference is a soft preference for when during the week a meeting takes
+// place, such as "in the morning", "after lunch" or "not on Friday".
+type TimePreference struct {
+	// Location is the time zone in which the windows are expressed. If nil,
+	// the location of the earliest time given to New is used.
+	Location *time.Location
+	// Preferred are the weekly recurring windows the meeting preferably is
+	// within. Any time is preferred if empty.
+	Preferred []WeeklyWindow
+	// Avoided are the weekly recurring windows the meeting preferably isn't
+	// within.
+	Avoided []WeeklyWindow
+}
+
+// Daily returns windows which are open between start and end (offsets from
+// local midnight) every day of the week.
+func Daily(start, end time.Duration) []WeeklyWindow {
+	windows := make([]WeeklyWindow, 0, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		windows = append(windows, WeeklyWindow{d, start, end})
+	}
+	return windows
+}
+
+// PreferenceCost is a CostFunction which scores the TimePreference of requests.
+// Every meeting costs the time it is outside of its preferred windows plus the
+// time it is within its avoided windows.
+var PreferenceCost CostFunction = CostFunc(preferenceCost)
+
+func preferenceCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, event := range schedule.Events() {
+		pref := event.Request.Preference
+		if pref == nil {
+			continue
+		}
+		loc := pref.Location
+		if loc == nil {
+			loc = schedule.Earliest().Location()
+		}
+		if len(pref.Preferred) > 0 {
+			score += event.End.Sub(event.Start) - timeWithin(pref.Preferred, event.TimeInterval, loc)
+		}
+		score += timeWithin(pref.Avoided, event.TimeInterval, loc)
+	}
+	return float64(score)
+}
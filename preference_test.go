@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotBefore(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, NotBefore: now.Add(2 * time.Hour)},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, NotBefore: now.Add(2 * time.Hour), Deadline: now.Add(150 * time.Minute)},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := now.Add(2 * time.Hour); len(schedule.Events) != 1 || !schedule.Events[0].Start.Equal(expected) {
+		t.Error("Expected the meeting to start at", expected, "Events:", schedule.Events)
+	}
+	if len(schedule.Unscheduled) != 1 || schedule.Unscheduled[0].Reason != ExceededHorizon {
+		t.Error("Expected a too narrow window to exceed the horizon:", schedule.Unscheduled)
+	}
+}
+
+func TestPreferenceCost(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	morning := &TimePreference{Preferred: Daily(8*time.Hour, 12*time.Hour)}
+	notMonday := &TimePreference{Avoided: []WeeklyWindow{{time.Monday, 0, 24 * time.Hour}}}
+
+	tests := []struct {
+		name       string
+		preference *TimePreference
+		start      time.Time
+		expected   time.Duration
+	}{
+		{"within preferred", morning, now, 0},
+		{"partly outside preferred", morning, now.Add(150 * time.Minute), 30 * time.Minute},
+		{"outside preferred", morning, now.Add(24*time.Hour + 5*time.Hour), 60 * time.Minute},
+		{"within avoided", notMonday, now, 60 * time.Minute},
+		{"outside avoided", notMonday, now.Add(24 * time.Hour), 0},
+	}
+	for _, test := range tests {
+		schedule := constructedSchedule{
+			earliest: now,
+			Events: []ScheduledEvent{{
+				TimeInterval: TimeInterval{test.start, test.start.Add(60 * time.Minute)},
+				Request:      &ScheduleRequest{Length: 60 * time.Minute, Preference: test.preference},
+			}},
+		}
+		if c := PreferenceCost.Cost(ScheduleView{&schedule}); c != float64(test.expected) {
+			t.Errorf("%s: Expected: %s Was: %s", test.name, test.expected, time.Duration(c))
+		}
+	}
+}
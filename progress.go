@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+// Progress is a snapshot of how the genetic algorithm is doing. It is reported
+// after every generation to the callback given to WithProgress.
+type Progress struct {
+	// Generation is the number of generations that have been executed.
+	Generation uint
+	// BestFitness is the fitness of the best schedule found so far. Lower is
+	// better.
+	BestFitness float64
+	// Diversity is the fraction, between 0 and 1, of distinct schedule
+	// candidates in the populations. A low diversity means that the
+	// populations have converged.
+	Diversity float64
+	// Elapsed is the time that has passed since the run started.
+	Elapsed time.Duration
+}
+
+// WithProgress is an optional configuration option which makes the scheduler
+// call f after every generation of the genetic algorithm. It can be used to
+// log convergence or to show a progress bar.
+func WithProgress(f func(Progress)) Config {
+	return func(c *Scheduler) {
+		c.progress = f
+	}
+}
+
+// newProgress creates a Progress for the current state of ga.
+func newProgress(ga *eaopt.GA, started time.Time) Progress {
+	p := Progress{
+		Generation: ga.Generations,
+		Diversity:  diversity(ga.Populations),
+		Elapsed:    time.Since(started),
+	}
+	if len(ga.HallOfFame) > 0 {
+		p.BestFitness = ga.HallOfFame[0].Fitness
+	}
+	return p
+}
+
+// diversity returns the fraction of distinct candidates in pops.
+func diversity(pops eaopt.Populations) float64 {
+	seen := make(map[string]struct{})
+	total := 0
+	for _, pop := range pops {
+		for _, indi := range pop.Individuals {
+			c, ok := indi.Genome.(*candidate)
+			if !ok {
+				continue
+			}
+			total++
+			seen[c.key()] = struct{}{}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(len(seen)) / float64(total)
+}
+
+// key returns a string which uniquely identifies the order of a candidate.
+func (s *candidate) key() string {
+	parts := make([]string, len(s.order))
+	for i, o := range s.order {
+		parts[i] = strconv.Itoa(o)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressIsReportedEveryGeneration(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	var reported []Progress
+	scheduler, err := New(now, reqs, NGenerations(10), WithProgress(func(p Progress) {
+		reported = append(reported, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scheduler.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reported) < 10 {
+		t.Fatal("Expected progress for every generation. Was:", len(reported))
+	}
+	if last := reported[len(reported)-1].Generation; last != 10 {
+		t.Error("Unexpected last generation. Expected: 10 Was:", last)
+	}
+	for i, p := range reported {
+		if i > 0 && p.Generation != reported[i-1].Generation+1 {
+			t.Error("Expected consecutive generations. Was:", reported[i-1].Generation, p.Generation)
+		}
+		if p.Diversity <= 0 || p.Diversity > 1 {
+			t.Error("Diversity out of range:", p.Diversity)
+		}
+		if i > 0 && p.BestFitness > reported[i-1].BestFitness {
+			t.Error("Best fitness got worse between generations.")
+		}
+		if i > 0 && p.Elapsed < reported[i-1].Elapsed {
+			t.Error("Elapsed time decreased between generations.")
+		}
+	}
+}
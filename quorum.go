@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// AttendeePool is a group of interchangeable attendees of which only a minimum
+// number needs to attend a meeting, e.g. "any 3 of these 5 people".
+type AttendeePool struct {
+	// Attendees are the members of the pool.
+	Attendees []Attendee
+	// Min is the number of members that attend the meeting. Exactly Min
+	// members are picked among the available ones, preferring the members
+	// that have the fewest meetings in the schedule so far to spread the
+	// load fairly.
+	Min int
+}
+
+// validate checks that p has enough members to ever reach its minimum.
+func (p AttendeePool) validate() error {
+	if p.Min < 0 {
+		return errors.New("pool minimum must not be negative")
+	}
+	if p.Min > len(p.Attendees) {
+		return errors.New("pool minimum must not exceed the number of members")
+	}
+	return nil
+}
+
+// attendeeCount returns the number of attendees that are needed for req to be
+// scheduled.
+func (req *ScheduleRequest) attendeeCount() int {
+	count := len(req.Attendees)
+	for _, pool := range req.Pools {
+		count += pool.Min
+	}
+	return count
+}
+
+// findQuorum picks the members of the pools of se's request that attend se. If
+// any pool lacks enough available members it returns false and the next time
+// to try, which is zero if the pool never will have enough.
+func (c *constructedSchedule) findQuorum(se ScheduledEvent) ([]Attendee, time.Time, bool, error) {
+	picked := make(map[AttendeeID]bool)
+	for _, a := range se.Attendees {
+		picked[a.ID] = true
+	}
+
+	var members []Attendee
+	for _, pool := range se.Request.Pools {
+		var available []Attendee
+		var nexts []time.Time
+		for _, a := range pool.Attendees {
+			if picked[a.ID] {
+				// Already attending as a required attendee or a member of
+				// another pool.
+				continue
+			}
+			free, next, err := c.findAttendeeAvailability(a, se)
+			if err != nil {
+				return nil, time.Time{}, false, err
+			}
+			if free {
+				available = append(available, a)
+			} else if !next.IsZero() {
+				nexts = append(nexts, next)
+			}
+		}
+
+		if missing := pool.Min - len(available); missing > 0 {
+			if len(nexts) < missing {
+				return nil, time.Time{}, false, nil
+			}
+			// Nothing changes before enough of the unavailable members
+			// could have become available.
+			sort.Slice(nexts, func(i, j int) bool { return nexts[i].Before(nexts[j]) })
+			return nil, nexts[missing-1], false, nil
+		}
+
+		sort.SliceStable(available, func(i, j int) bool {
+			return c.load(available[i].ID) < c.load(available[j].ID)
+		})
+		for _, a := range available[:pool.Min] {
+			picked[a.ID] = true
+			members = append(members, a)
+		}
+	}
+	return members, time.Time{}, true, nil
+}
+
+// load returns the number of meetings that have been scheduled for attendee id
+// so far.
+func (c *constructedSchedule) load(id AttendeeID) int {
+	if e, exists := c.eventsByAttendee[id]; exists {
+		return len(e.Scheduled)
+	}
+	return 0
+}
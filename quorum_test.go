@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestQuorum(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	pool := AttendeePool{
+		Attendees: []Attendee{
+			{ID: "a", Calendar: FakeCalendar{{now, now.Add(60 * time.Minute)}}},
+			{ID: "b", Calendar: emptyCalendar},
+			{ID: "c", Calendar: emptyCalendar},
+		},
+		Min: 2,
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Pools: []AttendeePool{pool}, PossibleRooms: rooms},
+		{Length: 60 * time.Minute, Pools: []AttendeePool{pool}, PossibleRooms: rooms},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(attendees []Attendee) []AttendeeID {
+		var result []AttendeeID
+		for _, a := range attendees {
+			result = append(result, a.ID)
+		}
+		return result
+	}
+
+	if len(schedule.Events) != 2 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	first := schedule.Events[0]
+	if !first.Start.Equal(now) {
+		t.Error("Expected the available members to meet right away. Start:", first.Start)
+	}
+	if got := ids(first.Attendees); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("Unexpected attendees of first event: %v", got)
+	}
+
+	second := schedule.Events[1]
+	if expected := now.Add(60 * time.Minute); !second.Start.Equal(expected) {
+		t.Error("Unexpected start of second event. Expected:", expected, "Was:", second.Start)
+	}
+	if got := ids(second.Attendees); len(got) != 2 || got[0] != "a" {
+		t.Errorf("Expected the least loaded member to be picked first: %v", got)
+	}
+
+	for _, min := range []int{-1, 4} {
+		if _, err := New(now, []*ScheduleRequest{{Length: 60 * time.Minute, Pools: []AttendeePool{{Attendees: pool.Attendees, Min: min}}}}); err == nil {
+			t.Errorf("Expected an error for a pool minimum of %d.", min)
+		}
+	}
+}
+
+func TestQuorumTravel(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	a1 := Room{ID: "a1", Calendar: emptyCalendar, Building: "a"}
+	b1 := Room{ID: "b1", Calendar: emptyCalendar, Building: "b"}
+	near := Attendee{ID: "p", Calendar: emptyCalendar}
+	far := Attendee{ID: "q", Calendar: emptyCalendar}
+	busy := Attendee{ID: "y", Calendar: FakeCalendar{{now, now.Add(60 * time.Minute)}}}
+	reqs := []*ScheduleRequest{
+		// Make the member that can travel in time the more loaded one.
+		{Length: 60 * time.Minute, Attendees: []Attendee{far}, PossibleRooms: []Room{b1}, NotBefore: now.Add(5 * time.Hour)},
+		{Length: 60 * time.Minute, Attendees: []Attendee{far}, PossibleRooms: []Room{b1}, NotBefore: now.Add(6 * time.Hour)},
+		{Length: 60 * time.Minute, Attendees: []Attendee{near}, PossibleRooms: []Room{a1}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{busy}, Pools: []AttendeePool{{Attendees: []Attendee{near, far}, Min: 1}}, PossibleRooms: []Room{b1}},
+	}
+
+	scheduler, err := New(now, reqs, Travel(TravelMatrix{"a": {"b": 20 * time.Minute}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2, 3}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule.Events) != 4 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	e := schedule.Events[3]
+	if !e.Start.Equal(now.Add(60*time.Minute)) || len(e.Attendees) != 2 || e.Attendees[1].ID != "q" {
+		t.Errorf("Expected the member that can travel in time to be picked:\n%s", pp.Sprint(e))
+	}
+}
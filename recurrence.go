@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a recurring meeting recurs.
+type Frequency int
+
+const (
+	// Weekly meetings recur every week.
+	Weekly Frequency = iota
+	// Monthly meetings recur every month on the same day of the month, or on
+	// the last day of months that are too short.
+	Monthly
+)
+
+// Recurrence makes a request schedule a whole series of meetings, such as a
+// weekly 1:1 for the next quarter. All occurrences are placed at the same local
+// time as the first one where possible. An occurrence whose usual time is
+// blocked is moved to the earliest possible time before the next occurrence,
+// and skipped if there is no such time. The series is scheduled as
+// ScheduledEvents that reference the same ScheduleRequest.
+//
+// Only the first occurrence is bound by the latest time of the Scheduler and
+// the Deadline of the request, so a series can extend beyond the horizon of a
+// batch.
+type Recurrence struct {
+	// Frequency is how often the meeting recurs.
+	Frequency Frequency
+	// Interval is the number of weeks or months between occurrences. For
+	// example, a biweekly meeting recurs weekly with an interval of 2. Zero
+	// means 1.
+	Interval int
+	// Count is the number of occurrences. If zero, Until is used.
+	Count int
+	// Until is the time the last occurrence starts at the latest.
+	Until time.Time
+	// Location is the time zone in which the local time of the occurrences
+	// is kept. If nil, the location of the earliest time given to New is
+	// used.
+	Location *time.Location
+	// Except are days, in Location, on which occurrences are skipped, like
+	// EXDATE in iCalendar. Only the calendar date of each is used, whatever
+	// its location. The first occurrence is never placed on them.
+	Except []time.Time
+
+	// untilDay is set if Until only is a date, like a date-only UNTIL in an
+	// RRULE. The whole day, in Location, is then included in the series.
+	untilDay bool
+}
+
+// SkippedOccurrence is an occurrence of a recurring ScheduleRequest that
+// wasn't scheduled.
+type SkippedOccurrence struct {
+	// Request is the recurring request.
+	Request *ScheduleRequest
+	// Occurrence is the index of the occurrence.
+	Occurrence int
+	// Start is the usual start of the occurrence.
+	Start time.Time
+	// Reason is why the occurrence was skipped. It's Excepted for
+	// occurrences on a day in Recurrence.Except.
+	Reason UnscheduledReason
+
+	// period is the time between the usual start of the occurrence and the
+	// next one.
+	period time.Duration
+}
+
+// cost is the penalty for skipping the occurrence. It's the same as delaying
+// it until the next occurrence. Skipping an excepted occurrence is free.
+func (s SkippedOccurrence) cost() time.Duration {
+	if s.Reason == Excepted {
+		return 0
+	}
+	return time.Duration(s.Request.attendeeCount()) * s.period
+}
+
+// ParseRRule parses the subset of an iCalendar RRULE (RFC 5545) that maps to a
+// Recurrence: FREQ (WEEKLY or MONTHLY), INTERVAL, COUNT and UNTIL. For
+// example "FREQ=WEEKLY;INTERVAL=2;COUNT=6". A date-only UNTIL includes the
+// whole day in the Location of the Recurrence.
+func ParseRRule(rule string) (*Recurrence, error) {
+	var r Recurrence
+	hasFreq := false
+	for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed rule part: %q", part)
+		}
+		var err error
+		switch key, value := kv[0], kv[1]; key {
+		case "FREQ":
+			hasFreq = true
+			switch value {
+			case "WEEKLY":
+				r.Frequency = Weekly
+			case "MONTHLY":
+				r.Frequency = Monthly
+			default:
+				return nil, fmt.Errorf("unsupported frequency: %q", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = time.Parse("20060102T150405Z", value)
+			if err != nil {
+				r.Until, err = time.Parse("20060102", value)
+				r.untilDay = err == nil
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part: %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed rule part %q: %v", part, err)
+		}
+	}
+	if !hasFreq {
+		return nil, errors.New("rule lacks FREQ")
+	}
+	return &r, r.validate()
+}
+
+// validate checks that r describes a finite series.
+func (r *Recurrence) validate() error {
+	if r.Interval < 0 || r.Count < 0 {
+		return errors.New("recurrence interval and count must not be negative")
+	}
+	if r.Count == 0 && r.Until.IsZero() {
+		return errors.New("recurrence needs a count or an until time")
+	}
+	return nil
+}
+
+// occurrence returns the usual start of the k:th occurrence of a series whose
+// first occurrence starts at first.
+func (r *Recurrence) occurrence(first time.Time, k int, loc *time.Location) time.Time {
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	first = first.In(loc)
+	if r.Frequency == Monthly {
+		// Contrary to AddDate, don't let the 29th to 31st spill over into
+		// the month after.
+		y, m, d := first.Date()
+		m += time.Month(k * interval)
+		if last := time.Date(y, m+1, 0, 0, 0, 0, 0, loc).Day(); d > last {
+			d = last
+		}
+		return time.Date(y, m, d, first.Hour(), first.Minute(), first.Second(), first.Nanosecond(), loc)
+	}
+	return first.AddDate(0, 0, 7*k*interval)
+}
+
+// ended checks if an occurrence starting at t is past the end of a series
+// without a Count.
+func (r *Recurrence) ended(t time.Time, loc *time.Location) bool {
+	if r.Count > 0 {
+		return false
+	}
+	if r.untilDay {
+		y, m, d := r.Until.Date()
+		return !t.Before(time.Date(y, m, d+1, 0, 0, 0, 0, loc))
+	}
+	return t.After(r.Until)
+}
+
+// excepted checks if t is on one of the days of r.Except in loc.
+func (r *Recurrence) excepted(t time.Time, loc *time.Location) bool {
+	for _, day := range r.Except {
+		if sameDate(day, t.In(loc)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDate checks if a and b have the same calendar date, each in its own
+// location.
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// placeSeries places the occurrences of req, which must have a Recurrence. The
+// first occurrence is placed at or after start. It also returns the
+// occurrences that were skipped.
+func (c *constructedSchedule) placeSeries(req *ScheduleRequest, start, latestStart time.Time) ([]ScheduledEvent, []SkippedOccurrence, error) {
+	r := req.Recurrence
+	loc := r.Location
+	if loc == nil {
+		loc = c.earliest.Location()
+	}
+
+	var first ScheduledEvent
+	for iterations := 0; ; iterations++ {
+		if iterations >= MaxIterations {
+			return nil, nil, &unschedulableError{reason: NoCommonFreeTime}
+		}
+		se, err := c.place(req, req.Length, start, latestStart, c.deadline(req))
+		if err != nil {
+			return nil, nil, err
+		}
+		if !r.excepted(se.Start, loc) {
+			first = se
+			break
+		}
+		start = localMidnight(se.Start, loc).AddDate(0, 0, 1)
+	}
+	if r.ended(first.Start, loc) {
+		return nil, nil, &unschedulableError{reason: ExceededHorizon}
+	}
+
+	// Commit the occurrences tentatively so that buffers, limits, focus
+	// time and travel take them into account when placing the following
+	// ones. Add commits them for real.
+	series := []ScheduledEvent{first}
+	c.commit(first)
+	defer func() { c.rollback(series) }()
+
+	var skipped []SkippedOccurrence
+	for k := 1; ; k++ {
+		usual := r.occurrence(first.Start, k, loc)
+		if (r.Count > 0 && k >= r.Count) || r.ended(usual, loc) {
+			return series, skipped, nil
+		}
+		next := r.occurrence(first.Start, k+1, loc)
+		if r.excepted(usual, loc) {
+			skipped = append(skipped, SkippedOccurrence{req, k, usual, Excepted, next.Sub(usual)})
+			continue
+		}
+
+		// An occurrence may be moved, but not past the usual time of the
+		// next one.
+		se, err := c.place(req, req.Length, latest(usual, series[len(series)-1].End), next.Add(-req.Length), time.Time{})
+		if u, ok := err.(*unschedulableError); ok {
+			reason := u.reason
+			if u.blocker != "" {
+				reason = u.blocker
+			}
+			skipped = append(skipped, SkippedOccurrence{req, k, usual, reason, next.Sub(usual)})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		se.Occurrence = k
+		c.commit(se)
+		series = append(series, se)
+	}
+}
@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestParseRRule(t *testing.T) {
+	until, _ := time.Parse("02-01-2006 15:04", "01-03-2020 00:00")
+	tests := []struct {
+		rule     string
+		expected *Recurrence
+	}{
+		{"FREQ=WEEKLY;COUNT=12", &Recurrence{Frequency: Weekly, Count: 12}},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20200301T000000Z", &Recurrence{Frequency: Weekly, Interval: 2, Until: until}},
+		{"FREQ=MONTHLY;UNTIL=20200301", &Recurrence{Frequency: Monthly, Until: until, untilDay: true}},
+		{"FREQ=DAILY;COUNT=3", nil},
+		{"FREQ=WEEKLY;BYDAY=MO;COUNT=3", nil},
+		{"FREQ=WEEKLY", nil},
+		{"COUNT=3", nil},
+	}
+	for _, test := range tests {
+		r, err := ParseRRule(test.rule)
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("%s: Expected an error.", test.rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %s", test.rule, err)
+			continue
+		}
+		if !reflect.DeepEqual(r, test.expected) {
+			t.Errorf("%s: Expected: %+v Was: %+v", test.rule, test.expected, r)
+		}
+	}
+}
+
+func TestMonthlyOccurrences(t *testing.T) {
+	first, _ := time.Parse("02-01-2006 15:04", "31-01-2020 09:00")
+	monthly := &Recurrence{Frequency: Monthly, Count: 4}
+	expected := []string{"31-01-2020 09:00", "29-02-2020 09:00", "31-03-2020 09:00", "30-04-2020 09:00"}
+	for k, e := range expected {
+		expected, _ := time.Parse("02-01-2006 15:04", e)
+		if o := monthly.occurrence(first, k, time.UTC); !o.Equal(expected) {
+			t.Errorf("Unexpected occurrence %d. Expected: %s Was: %s", k, expected, o)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	blockedWeek := now.AddDate(0, 0, 14)
+	attendee := Attendee{ID: "a", Calendar: FakeCalendar{{blockedWeek, blockedWeek.Add(60 * time.Minute)}}}
+	biweekly := &Recurrence{Frequency: Weekly, Interval: 2, Count: 3}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, Recurrence: biweekly},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{now, blockedWeek.Add(60 * time.Minute), now.AddDate(0, 0, 28)}
+	if len(schedule.Events) != len(expected) {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	for i, e := range schedule.Events {
+		if !e.Start.Equal(expected[i]) || e.Occurrence != i || e.Request != reqs[0] {
+			t.Errorf("Unexpected occurrence %d. Expected start: %s Was:\n%s", i, expected[i], pp.Sprint(e))
+		}
+	}
+
+	if _, err := New(now, []*ScheduleRequest{{Recurrence: &Recurrence{Frequency: Weekly}}}); err == nil {
+		t.Error("Expected an error for an endless series.")
+	}
+}
+
+func TestRecurrenceUntilDate(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	weekly, err := ParseRRule("FREQ=WEEKLY;UNTIL=20191216")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Midnight in a time zone ahead of the series is still the 9th.
+	weekly.Except = []time.Time{time.Date(2019, 12, 9, 0, 0, 0, 0, time.FixedZone("UTC+10", 10*60*60))}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{{ID: "a", Calendar: emptyCalendar}}, PossibleRooms: rooms, Recurrence: weekly},
+	}
+
+	scheduler, err := New(now, reqs, Latest(now.AddDate(0, 0, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{now, now.AddDate(0, 0, 14)}
+	if len(schedule.Events) != len(expected) {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	for i, e := range schedule.Events {
+		if !e.Start.Equal(expected[i]) {
+			t.Errorf("Unexpected occurrence %d. Expected start: %s Was: %s", i, expected[i], e.Start)
+		}
+	}
+	if len(schedule.Skipped) != 1 || schedule.Skipped[0].Reason != Excepted || !schedule.Skipped[0].Start.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("Unexpected skipped occurrences:\n%s", pp.Sprint(schedule.Skipped))
+	}
+}
+
+func TestRecurrenceExceptions(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	blockedWeek := now.AddDate(0, 0, 7)
+	exceptedWeek := now.AddDate(0, 0, 14)
+	attendee := Attendee{ID: "a", Calendar: FakeCalendar{{blockedWeek, blockedWeek.AddDate(0, 0, 7)}}}
+	weekly := &Recurrence{Frequency: Weekly, Count: 4, Except: []time.Time{exceptedWeek}}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, Recurrence: weekly},
+	}
+
+	// The horizon of the batch only covers the first occurrence.
+	scheduler, err := New(now, reqs, Latest(now.AddDate(0, 0, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{now, now.AddDate(0, 0, 21)}
+	if len(schedule.Events) != len(expected) {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	for i, e := range schedule.Events {
+		if !e.Start.Equal(expected[i]) {
+			t.Errorf("Unexpected occurrence %d. Expected start: %s Was: %s", i, expected[i], e.Start)
+		}
+	}
+	if schedule.Events[1].Occurrence != 3 {
+		t.Error("Unexpected occurrence index:", schedule.Events[1].Occurrence)
+	}
+
+	skipped := schedule.Skipped
+	if len(skipped) != 2 ||
+		skipped[0].Occurrence != 1 || skipped[0].Reason != NoCommonFreeTime || !skipped[0].Start.Equal(blockedWeek) ||
+		skipped[1].Occurrence != 2 || skipped[1].Reason != Excepted || !skipped[1].Start.Equal(exceptedWeek) {
+		t.Errorf("Unexpected skipped occurrences:\n%s", pp.Sprint(skipped))
+	}
+	if len(schedule.Unscheduled) != 0 {
+		t.Errorf("Expected the series to be scheduled:\n%s", pp.Sprint(schedule.Unscheduled))
+	}
+
+	// Only the blocked occurrence is penalized, as if it was delayed a week.
+	if c, expected := schedule.Evaluate(), float64(7*24*time.Hour); c != expected {
+		t.Errorf("Unexpected cost. Expected: %s Was: %s", time.Duration(expected), time.Duration(c))
+	}
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// RoomFeature is a feature of a room, such as "video-conferencing",
+// "whiteboard" or "wheelchair-accessible".
+type RoomFeature string
+
+// fits checks if room has enough seats for n attendees.
+func (room Room) fits(n int) bool {
+	return room.Capacity == 0 || room.Capacity >= n
+}
+
+// has checks if room has all of features.
+func (room Room) has(features []RoomFeature) bool {
+	for _, f := range features {
+		found := false
+		for _, g := range room.Features {
+			if f == g {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// suitableRooms returns the possible rooms of req that fit its on-site
+// attendees and have its required features, smallest first. Rooms with unknown
+// capacity are last.
+func suitableRooms(req *ScheduleRequest) []Room {
+	n := req.seats()
+	var result []Room
+	for _, room := range req.PossibleRooms {
+		if room.fits(n) && room.has(req.RequiredFeatures) {
+			result = append(result, room)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Capacity, result[j].Capacity
+		return a != 0 && (b == 0 || a < b)
+	})
+	return result
+}
+
+// admitOptional splits the available optional attendees into the ones that
+// there are seats left for in room after attending have taken theirs and the
+// ones that there aren't. Remote attendees don't need seats.
+func admitOptional(room Room, attending, available []Attendee) ([]Attendee, []Attendee) {
+	if room.Capacity == 0 {
+		return available, nil
+	}
+	free := room.Capacity
+	for _, a := range attending {
+		if !a.Remote {
+			free--
+		}
+	}
+	var admitted, overflow []Attendee
+	for _, a := range available {
+		switch {
+		case a.Remote:
+			admitted = append(admitted, a)
+		case free > 0:
+			free--
+			admitted = append(admitted, a)
+		default:
+			overflow = append(overflow, a)
+		}
+	}
+	return admitted, overflow
+}
+
+// WastedSeatsCost is a CostFunction which prefers right-sized rooms. Every
+// seat in the room of a meeting that isn't taken by an on-site attendee costs
+// the length of the meeting. Rooms with unknown capacity are free.
+var WastedSeatsCost CostFunction = CostFunc(wastedSeatsCost)
+
+func wastedSeatsCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, event := range schedule.Events() {
+		wasted := event.Room.Capacity
+		for _, a := range event.Attendees {
+			if !a.Remote {
+				wasted--
+			}
+		}
+		if event.Room.Capacity > 0 && wasted > 0 {
+			score += time.Duration(wasted) * event.End.Sub(event.Start)
+		}
+	}
+	return float64(score)
+}
+
+// BuildingID is a unique identifier for a building.
+type BuildingID string
+
+// TravelMatrix holds the time it takes to travel between buildings. A missing
+// entry from one building to another falls back on the entry in the opposite
+// direction. Buildings without entries are considered to be next to each
+// other.
+type TravelMatrix map[BuildingID]map[BuildingID]time.Duration
+
+// between returns the time it takes to travel from one building to another.
+func (m TravelMatrix) between(from, to BuildingID) time.Duration {
+	if from == "" || to == "" || from == to {
+		return 0
+	}
+	if d, ok := m[from][to]; ok {
+		return d
+	}
+	return m[to][from]
+}
+
+// findTravelConflict checks if any of se's on-site attendees lacks time to
+// travel between room and the rooms of their meetings before and after se. If
+// so, it returns the next time to try.
+func (c *constructedSchedule) findTravelConflict(se ScheduledEvent, room Room) (time.Time, bool) {
+	for _, a := range se.Attendees {
+		e, exists := c.eventsByAttendee[a.ID]
+		if !exists || a.Remote {
+			continue
+		}
+		for _, scheduled := range e.Scheduled {
+			travel := c.travel.between(scheduled.Room.Building, room.Building)
+			if travel == 0 {
+				continue
+			}
+			if scheduled.TimeInterval.padded(travel).Overlaps(se.TimeInterval) {
+				return scheduled.End.Add(travel), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// DefaultRoomSwitch is what RoomSwitchCost charges for switching between two
+// rooms in the same building.
+const DefaultRoomSwitch = 5 * time.Minute
+
+// RoomSwitchCost is a CostFunction which prefers attendees to stay in the same
+// room. Every switch of rooms between two consecutive meetings of an on-site
+// attendee on the same local day costs DefaultRoomSwitch plus the time it takes
+// to travel between the rooms. Meetings over video only don't count.
+var RoomSwitchCost CostFunction = CostFunc(roomSwitchCost)
+
+func roomSwitchCost(schedule ScheduleView) float64 {
+	var score time.Duration
+	for _, id := range schedule.Attendees() {
+		attendee := schedule.c.eventsByAttendee[id]
+		if attendee.Attendee.Remote {
+			continue
+		}
+		loc := schedule.Location(attendee.Attendee)
+		for i, event := range attendee.Scheduled[1:] {
+			prev := attendee.Scheduled[i]
+			if prev.Room.ID == event.Room.ID || prev.Room.ID == "" || event.Room.ID == "" || !sameLocalDay(prev.Start, event.Start, loc) {
+				continue
+			}
+			score += DefaultRoomSwitch + schedule.c.travel.between(prev.Room.Building, event.Room.Building)
+		}
+	}
+	return float64(score)
+}
@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestRoomCapacity(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	unknown := Room{ID: "unknown", Calendar: emptyCalendar}
+	board := Room{ID: "board", Calendar: FakeCalendar{{now, now.Add(60 * time.Minute)}}, Capacity: 20}
+	small := Room{ID: "small", Calendar: emptyCalendar, Capacity: 4}
+
+	var many []Attendee
+	for i := 0; i < 12; i++ {
+		many = append(many, Attendee{ID: AttendeeID(fmt.Sprint("a", i)), Calendar: emptyCalendar})
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: many[:2], PossibleRooms: []Room{unknown, board, small}},
+		{Length: 60 * time.Minute, Attendees: many[2:], PossibleRooms: []Room{small, board}},
+		{Length: 60 * time.Minute, Attendees: many[2:], PossibleRooms: []Room{small}},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule.Events) != 2 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	if e := schedule.Events[0]; e.Room.ID != "small" || !e.Start.Equal(now) {
+		t.Errorf("Expected the smallest room to be used:\n%s", pp.Sprint(e))
+	}
+	if e, expected := schedule.Events[1], now.Add(60*time.Minute); e.Room.ID != "board" || !e.Start.Equal(expected) {
+		t.Errorf("Expected the meeting to wait for a large enough room:\n%s", pp.Sprint(e))
+	}
+	if len(schedule.Unscheduled) != 1 || schedule.Unscheduled[0].Reason != NoRoomAvailable {
+		t.Errorf("Expected a too small room to be unavailable:\n%s", pp.Sprint(schedule.Unscheduled))
+	}
+
+	// 2 empty seats in the small room and 10 in the board room.
+	if c, expected := WastedSeatsCost.Cost(ScheduleView{&schedule}), float64(12*time.Hour); c != expected {
+		t.Errorf("Unexpected cost. Expected: %s Was: %s", time.Duration(expected), time.Duration(c))
+	}
+}
+
+func TestRoomFeatures(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	plain := Room{ID: "plain", Calendar: emptyCalendar}
+	whiteboard := Room{ID: "whiteboard", Calendar: emptyCalendar, Features: []RoomFeature{"whiteboard"}}
+	equipped := Room{ID: "equipped", Calendar: emptyCalendar, Features: []RoomFeature{"video-conferencing", "whiteboard"}}
+	rooms := []Room{plain, whiteboard, equipped}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, RequiredFeatures: []RoomFeature{"video-conferencing", "whiteboard"}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, RequiredFeatures: []RoomFeature{"whiteboard"}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: rooms, RequiredFeatures: []RoomFeature{"wheelchair-accessible"}},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule.Events) != 2 || schedule.Events[0].Room.ID != "equipped" || schedule.Events[1].Room.ID != "whiteboard" {
+		t.Errorf("Unexpected rooms:\n%s", pp.Sprint(schedule.Events))
+	}
+	if len(schedule.Unscheduled) != 1 || schedule.Unscheduled[0].Reason != NoRoomAvailable {
+		t.Errorf("Expected no room to be available:\n%s", pp.Sprint(schedule.Unscheduled))
+	}
+}
+
+func TestTravelBetweenBuildings(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	a1 := Room{ID: "a1", Calendar: emptyCalendar, Building: "a"}
+	a2 := Room{ID: "a2", Calendar: emptyCalendar, Building: "a"}
+	b1 := Room{ID: "b1", Calendar: emptyCalendar, Building: "b"}
+	attendee := Attendee{ID: "a", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: []Room{a1}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: []Room{b1, a2}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee}, PossibleRooms: []Room{b1}},
+	}
+
+	travel := TravelMatrix{"a": {"b": 20 * time.Minute}}
+	scheduler, err := New(now, reqs, Travel(travel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1, 2}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		room  RoomID
+		start time.Time
+	}{
+		{"a1", now},
+		{"a2", now.Add(60 * time.Minute)},
+		{"b1", now.Add(140 * time.Minute)},
+	}
+	if len(schedule.Events) != len(expected) {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	for i, e := range expected {
+		if event := schedule.Events[i]; event.Room.ID != e.room || !event.Start.Equal(e.start) {
+			t.Errorf("Unexpected event %d. Expected: %s at %s Was: %s at %s", i, e.room, e.start, event.Room.ID, event.Start)
+		}
+	}
+
+	if c, expected := RoomSwitchCost.Cost(ScheduleView{&schedule}), float64(2*DefaultRoomSwitch+20*time.Minute); c != expected {
+		t.Errorf("Unexpected cost. Expected: %s Was: %s", time.Duration(expected), time.Duration(c))
+	}
+}
+
+func TestOptionalAttendeesFillRoom(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar, Capacity: 2},
+	}
+	attendee := func(id AttendeeID, remote bool) Attendee {
+		return Attendee{ID: id, Calendar: emptyCalendar, Remote: remote}
+	}
+	reqs := []*ScheduleRequest{
+		{
+			Length:            60 * time.Minute,
+			Attendees:         []Attendee{attendee("a", false)},
+			OptionalAttendees: []Attendee{attendee("b", false), attendee("c", true), attendee("d", false), attendee("e", false)},
+			PossibleRooms:     rooms,
+		},
+	}
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(attendees []Attendee) string {
+		var result string
+		for _, a := range attendees {
+			result += string(a.ID)
+		}
+		return result
+	}
+	if len(schedule.Events) != 1 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	event := schedule.Events[0]
+	if got := ids(event.Attendees); got != "abc" {
+		t.Error("Expected the room to be filled up with the first on-site optional attendee and the remote one. Was:", got)
+	}
+	if got := ids(event.UnavailableOptional); got != "de" {
+		t.Error("Expected the optional attendees without seats to be unavailable. Was:", got)
+	}
+}
+
+func TestOptionalAttendeesTravel(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	a1 := Room{ID: "a1", Calendar: emptyCalendar, Building: "a"}
+	b1 := Room{ID: "b1", Calendar: emptyCalendar, Building: "b"}
+	optional := Attendee{ID: "o", Calendar: emptyCalendar}
+	busy := Attendee{ID: "y", Calendar: FakeCalendar{{now, now.Add(60 * time.Minute)}}}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{{ID: "x", Calendar: emptyCalendar}}, OptionalAttendees: []Attendee{optional}, PossibleRooms: []Room{a1}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{busy}, OptionalAttendees: []Attendee{optional}, PossibleRooms: []Room{b1}},
+	}
+
+	scheduler, err := New(now, reqs, Travel(TravelMatrix{"a": {"b": 20 * time.Minute}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule.Events) != 2 {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(schedule.Events))
+	}
+	if e := schedule.Events[0]; len(e.Attendees) != 2 {
+		t.Errorf("Expected the optional attendee to attend the first meeting:\n%s", pp.Sprint(e))
+	}
+	if e := schedule.Events[1]; !e.Start.Equal(now.Add(60*time.Minute)) || len(e.UnavailableOptional) != 1 || e.UnavailableOptional[0].ID != "o" {
+		t.Errorf("Expected the optional attendee to lack time to travel to the second meeting:\n%s", pp.Sprint(e))
+	}
+}
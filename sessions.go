@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// SessionSpread restricts how the sessions of a split meeting are spread over
+// days.
+type SessionSpread int
+
+const (
+	// AnyDays places the sessions on any days.
+	AnyDays SessionSpread = iota
+	// SameDay places all sessions on the same day.
+	SameDay
+	// ConsecutiveDays places every session on the day after the previous
+	// session.
+	ConsecutiveDays
+)
+
+// Split allows a long meeting, such as a workshop, to be split into several
+// sessions when it doesn't fit, or fits later, in one block. The sessions are
+// scheduled as separate ScheduledEvents that reference the same
+// ScheduleRequest.
+type Split struct {
+	// MaxSessions is the maximum number of sessions the meeting is split
+	// into. The meeting is split into equally long sessions and the number of
+	// sessions whose last session ends the earliest is used.
+	MaxSessions int
+	// MinSessionLength is the minimum length of a session. Optional.
+	MinSessionLength time.Duration
+	// Gap is the minimum time between two sessions. Optional.
+	Gap time.Duration
+	// Spread restricts how the sessions are spread over days.
+	Spread SessionSpread
+	// Location is the time zone in which days are interpreted for Spread. If
+	// nil, the location of the earliest time given to New is used.
+	Location *time.Location
+}
+
+// sessionLengths returns the lengths of splitting length into n sessions. The
+// last session gets the remainder.
+func sessionLengths(length time.Duration, n int) []time.Duration {
+	lengths := make([]time.Duration, n)
+	for i := range lengths {
+		lengths[i] = length / time.Duration(n)
+	}
+	lengths[n-1] += length % time.Duration(n)
+	return lengths
+}
+
+// placeSessions places the sessions of req, which must have a Split, at or
+// after start. The number of sessions whose last session ends the earliest is
+// used.
+func (c *constructedSchedule) placeSessions(req *ScheduleRequest, start, latestStart time.Time) ([]ScheduledEvent, error) {
+	var best []ScheduledEvent
+	var firstErr error
+	for n := 1; n == 1 || n <= req.Split.MaxSessions; n++ {
+		lengths := sessionLengths(req.Length, n)
+		if n > 1 && lengths[0] < req.Split.MinSessionLength {
+			break
+		}
+		sessions, err := c.placeSplit(req, lengths, start, latestStart)
+		if _, ok := err.(*unschedulableError); ok {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || sessions[n-1].End.Before(best[len(best)-1].End) {
+			best = sessions
+		}
+	}
+	if best == nil {
+		return nil, firstErr
+	}
+	return best, nil
+}
+
+// placeSplit places one session of req per length at or after start, following
+// the rules of req.Split.
+func (c *constructedSchedule) placeSplit(req *ScheduleRequest, lengths []time.Duration, start, latestStart time.Time) ([]ScheduledEvent, error) {
+	split := req.Split
+	loc := split.Location
+	if loc == nil {
+		loc = c.earliest.Location()
+	}
+
+	for iterations := 0; iterations < MaxIterations; iterations++ {
+		var sessions []ScheduledEvent
+		from := start
+		restart := false
+		for i, length := range lengths {
+			// Only the first session is bound by the dependencies of the
+			// request.
+			bound := latestStart
+			if i > 0 {
+				bound = time.Time{}
+			}
+			se, err := c.place(req, length, from, bound, c.deadline(req))
+			if err != nil {
+				c.rollback(sessions)
+				return nil, err
+			}
+			if i > 0 {
+				first := localMidnight(sessions[0].Start, loc)
+				switch split.Spread {
+				case SameDay:
+					if !sameLocalDay(first, se.Start, loc) {
+						// Try again with all sessions on the day this
+						// session ended up on.
+						start = localMidnight(se.Start, loc)
+						restart = true
+					}
+				case ConsecutiveDays:
+					if !sameLocalDay(first.AddDate(0, 0, i), se.Start, loc) {
+						// Try again with the first session on the day
+						// that makes this session fall in place.
+						start = localMidnight(se.Start, loc).AddDate(0, 0, -i)
+						restart = true
+					}
+				}
+				if restart {
+					break
+				}
+			}
+
+			se.Session = i
+			// Commit the session tentatively so that buffers, limits,
+			// focus time and travel take it into account when placing the
+			// following sessions.
+			c.commit(se)
+			sessions = append(sessions, se)
+			from = se.End.Add(split.Gap)
+			if split.Spread == ConsecutiveDays {
+				from = latest(from, localMidnight(se.Start, loc).AddDate(0, 0, 1))
+			}
+		}
+		// Add commits the sessions once the number of sessions is decided.
+		c.rollback(sessions)
+		if !restart {
+			return sessions, nil
+		}
+	}
+	return nil, &unschedulableError{reason: NoCommonFreeTime}
+}
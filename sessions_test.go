@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestSplitIntoSessions(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	wh := WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour)
+	busy := Attendee{ID: "a", Calendar: FakeCalendar{{now.Add(2 * time.Hour), now.Add(4 * time.Hour)}}, WorkingHours: wh}
+	free := Attendee{ID: "b", Calendar: emptyCalendar, WorkingHours: wh}
+	tests := []struct {
+		name     string
+		req      *ScheduleRequest
+		expected []TimeInterval
+	}{
+		{
+			"earlier when split",
+			&ScheduleRequest{Length: 4 * time.Hour, Attendees: []Attendee{busy}, Split: &Split{MaxSessions: 2}},
+			[]TimeInterval{
+				{now, now.Add(2 * time.Hour)},
+				{now.Add(4 * time.Hour), now.Add(6 * time.Hour)},
+			},
+		},
+		{
+			"earlier in one block",
+			&ScheduleRequest{Length: 4 * time.Hour, Attendees: []Attendee{busy}, Split: &Split{MaxSessions: 2, Spread: SameDay, Gap: 4 * time.Hour}},
+			[]TimeInterval{
+				{now.Add(4 * time.Hour), now.Add(8 * time.Hour)},
+			},
+		},
+		{
+			"consecutive days",
+			&ScheduleRequest{Length: 10 * time.Hour, Attendees: []Attendee{free}, Split: &Split{MaxSessions: 3, MinSessionLength: 4 * time.Hour, Spread: ConsecutiveDays}},
+			[]TimeInterval{
+				{now, now.Add(5 * time.Hour)},
+				{now.Add(24 * time.Hour), now.Add(29 * time.Hour)},
+			},
+		},
+		{
+			"too short sessions",
+			&ScheduleRequest{Length: 10 * time.Hour, Attendees: []Attendee{free}, Split: &Split{MaxSessions: 3, MinSessionLength: 6 * time.Hour}},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		test.req.PossibleRooms = []Room{{ID: "room-1", Calendar: emptyCalendar}}
+		scheduler, err := New(now, []*ScheduleRequest{test.req})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sol := candidate{scheduler: scheduler, order: []int{0}}
+		schedule, err := sol.Schedule()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(schedule.Events) != len(test.expected) {
+			t.Errorf("%s: Unexpected events:\n%s", test.name, pp.Sprint(schedule.Events))
+			continue
+		}
+		if test.expected == nil && (len(schedule.Unscheduled) != 1 || schedule.Unscheduled[0].Reason != NoCommonFreeTime) {
+			t.Errorf("%s: Expected the request to be unschedulable:\n%s", test.name, pp.Sprint(schedule.Unscheduled))
+		}
+		for i, e := range schedule.Events {
+			if e.TimeInterval != test.expected[i] || e.Session != i || e.Request != test.req {
+				t.Errorf("%s: Unexpected session %d. Expected: %s Was: %s", test.name, i, test.expected[i], pp.Sprint(e))
+			}
+		}
+	}
+}
+
+func TestSessionsSeeEachOther(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+
+	// Two adjacent windows a day make a four hour meeting only fit as two
+	// back to back sessions.
+	var wh WorkingHours
+	for d := time.Monday; d <= time.Friday; d++ {
+		wh.Windows = append(wh.Windows, WeeklyWindow{d, 9 * time.Hour, 11 * time.Hour}, WeeklyWindow{d, 11 * time.Hour, 13 * time.Hour})
+	}
+	tuesday := now.AddDate(0, 0, 1)
+	tests := []struct {
+		name     string
+		attendee Attendee
+		spread   SessionSpread
+		expected []TimeInterval
+	}{
+		{
+			"buffer",
+			Attendee{ID: "a", Calendar: emptyCalendar, WorkingHours: &wh, Buffer: 30 * time.Minute},
+			AnyDays,
+			[]TimeInterval{{now, now.Add(2 * time.Hour)}, {tuesday, tuesday.Add(2 * time.Hour)}},
+		},
+		{
+			"limits",
+			Attendee{ID: "a", Calendar: emptyCalendar, WorkingHours: &wh, Limits: &MeetingLimits{MaxPerDay: 1}},
+			AnyDays,
+			[]TimeInterval{{now, now.Add(2 * time.Hour)}, {tuesday, tuesday.Add(2 * time.Hour)}},
+		},
+		{
+			"limits on the same day",
+			Attendee{ID: "a", Calendar: emptyCalendar, WorkingHours: &wh, Limits: &MeetingLimits{MaxPerDay: 1}},
+			SameDay,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		req := &ScheduleRequest{
+			Length:        4 * time.Hour,
+			Attendees:     []Attendee{test.attendee},
+			PossibleRooms: []Room{{ID: "room-1", Calendar: emptyCalendar}},
+			Split:         &Split{MaxSessions: 2, Spread: test.spread},
+		}
+		scheduler, err := New(now, []*ScheduleRequest{req})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sol := candidate{scheduler: scheduler, order: []int{0}}
+		schedule, err := sol.Schedule()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(schedule.Events) != len(test.expected) {
+			t.Errorf("%s: Unexpected events:\n%s", test.name, pp.Sprint(schedule.Events))
+			continue
+		}
+		for i, e := range schedule.Events {
+			if e.TimeInterval != test.expected[i] {
+				t.Errorf("%s: Unexpected session %d. Expected: %s Was: %s", test.name, i, test.expected[i], e.TimeInterval)
+			}
+		}
+		committed := 0
+		if e, exists := schedule.eventsByAttendee["a"]; exists {
+			committed = len(e.Scheduled)
+		}
+		if committed != len(test.expected) {
+			t.Errorf("%s: Expected tentative sessions to be rolled back. Events: %d", test.name, committed)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+// StopReason is a machine-readable reason for why the genetic algorithm
+// stopped.
+type StopReason string
+
+const (
+	// Completed means that all generations were executed.
+	Completed StopReason = "completed"
+	// Stagnated means that the best schedule didn't improve for the number
+	// of generations given to MaxStagnation.
+	Stagnated StopReason = "stagnated"
+	// TimeBudgetExceeded means that the duration given to TimeBudget passed.
+	TimeBudgetExceeded StopReason = "time_budget_exceeded"
+	// Cancelled means that the context given to RunContext was done.
+	Cancelled StopReason = "cancelled"
+)
+
+// MaxStagnation is an optional configuration option which stops the genetic
+// algorithm when the best schedule hasn't improved for n generations.
+func MaxStagnation(n uint) Config {
+	return func(c *Scheduler) {
+		c.maxStagnation = n
+	}
+}
+
+// MinImprovement is an optional configuration option which changes what
+// MaxStagnation considers to be an improvement. The best fitness must decrease
+// by at least ratio (for example 0.01 for 1%) relative to the previous best
+// fitness to count as an improvement. Defaults to any decrease at all. It isn't
+// a stopping criterion on its own, so New returns an error if it's used without
+// MaxStagnation.
+func MinImprovement(ratio float64) Config {
+	return func(c *Scheduler) {
+		c.minImprovement = ratio
+	}
+}
+
+// TimeBudget is an optional configuration option which stops the genetic
+// algorithm after the generation during which d has passed.
+func TimeBudget(d time.Duration) Config {
+	return func(c *Scheduler) {
+		c.timeBudget = d
+	}
+}
+
+// stopper decides when the genetic algorithm should stop early and keeps track
+// of why it did.
+type stopper struct {
+	scheduler *Scheduler
+	ctx       context.Context
+	started   time.Time
+
+	reason StopReason
+	// best is the best fitness that was seen at generation improved.
+	best     float64
+	improved uint
+}
+
+func newStopper(s *Scheduler, ctx context.Context) *stopper {
+	return &stopper{
+		scheduler: s,
+		ctx:       ctx,
+		started:   time.Now(),
+		reason:    Completed,
+		best:      math.Inf(1),
+	}
+}
+
+// stop implements eaopt.GAConfig.EarlyStop.
+func (st *stopper) stop(ga *eaopt.GA) bool {
+	if st.ctx.Err() != nil {
+		st.reason = Cancelled
+		return true
+	}
+	if budget := st.scheduler.timeBudget; budget > 0 && time.Since(st.started) >= budget {
+		st.reason = TimeBudgetExceeded
+		return true
+	}
+
+	if len(ga.HallOfFame) == 0 {
+		return false
+	}
+	fitness := ga.HallOfFame[0].Fitness
+	if math.IsInf(st.best, 1) || st.best-fitness > math.Abs(st.best)*st.scheduler.minImprovement {
+		st.best = fitness
+		st.improved = ga.Generations
+		return false
+	}
+	if n := st.scheduler.maxStagnation; n > 0 && ga.Generations-st.improved >= n {
+		st.reason = Stagnated
+		return true
+	}
+	return false
+}
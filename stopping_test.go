@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newStoppingTestScheduler(t *testing.T, options ...Config) *Scheduler {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	scheduler, err := New(now, reqs, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scheduler
+}
+
+func TestRunCompletesAllGenerations(t *testing.T) {
+	result, err := newStoppingTestScheduler(t, NGenerations(10)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != Completed {
+		t.Error("Unexpected stop reason. Expected:", Completed, "Was:", result.StopReason)
+	}
+	if result.Generations != 10 {
+		t.Error("Unexpected number of generations. Expected: 10 Was:", result.Generations)
+	}
+}
+
+func TestRunStopsWhenStagnating(t *testing.T) {
+	result, err := newStoppingTestScheduler(t, NGenerations(1<<31), MaxStagnation(5), MinImprovement(0.01)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != Stagnated {
+		t.Error("Unexpected stop reason. Expected:", Stagnated, "Was:", result.StopReason)
+	}
+	if result.Generations < 5 || result.Generations > 1000 {
+		t.Error("Unexpected number of generations:", result.Generations)
+	}
+}
+
+func TestMinImprovementRequiresMaxStagnation(t *testing.T) {
+	now := time.Now()
+	if _, err := New(now, nil, MinImprovement(0.01)); err == nil {
+		t.Error("Expected an error.")
+	}
+}
+
+func TestRunStopsWhenTimeBudgetIsExceeded(t *testing.T) {
+	result, err := newStoppingTestScheduler(t, NGenerations(1<<31), TimeBudget(50*time.Millisecond)).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != TimeBudgetExceeded {
+		t.Error("Unexpected stop reason. Expected:", TimeBudgetExceeded, "Was:", result.StopReason)
+	}
+}
+
+func TestRunReportsCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := newStoppingTestScheduler(t, NGenerations(1<<31)).RunContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != Cancelled {
+		t.Error("Unexpected stop reason. Expected:", Cancelled, "Was:", result.StopReason)
+	}
+}
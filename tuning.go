@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"math/rand"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+// CrossoverOperator is an operator that mates two schedule candidates.
+type CrossoverOperator int
+
+const (
+	// CycleCrossover is the cycle crossover (CX). It's the default.
+	CycleCrossover CrossoverOperator = iota
+	// PartiallyMappedCrossover is the partially mapped crossover (PMX).
+	PartiallyMappedCrossover
+	// OrderedCrossover is the ordered crossover (OX).
+	OrderedCrossover
+)
+
+// PopulationSize is an optional configuration option which changes the number
+// of schedule candidates in each population.
+func PopulationSize(n uint) Config {
+	return func(c *Scheduler) {
+		c.popSize = n
+	}
+}
+
+// NPopulations is an optional configuration option which changes the number of
+// populations (islands) that evolve in parallel.
+func NPopulations(n uint) Config {
+	return func(c *Scheduler) {
+		c.npops = n
+	}
+}
+
+// Migration is an optional configuration option which moves migrants
+// candidates between populations in a ring every frequency generations.
+// It only makes sense together with NPopulations.
+func Migration(frequency, migrants uint) Config {
+	return func(c *Scheduler) {
+		c.migFrequency = frequency
+		c.migrants = migrants
+	}
+}
+
+// Selection is an optional configuration option which changes how candidates
+// are selected for mating. Defaults to a tournament between three candidates.
+func Selection(selector eaopt.Selector) Config {
+	return func(c *Scheduler) {
+		c.selector = selector
+	}
+}
+
+// MutationRate is an optional configuration option which changes the
+// probability, between 0 and 1, that a candidate is mutated in a generation.
+func MutationRate(rate float64) Config {
+	return func(c *Scheduler) {
+		c.mutRate = &rate
+	}
+}
+
+// CrossoverRate is an optional configuration option which changes the
+// probability, between 0 and 1, that two candidates are mated in a generation.
+func CrossoverRate(rate float64) Config {
+	return func(c *Scheduler) {
+		c.crossRate = &rate
+	}
+}
+
+// MutationSwaps is an optional configuration option which changes the number of
+// requests that are swapped every time a candidate is mutated. Defaults to 1.
+func MutationSwaps(n int) Config {
+	return func(c *Scheduler) {
+		c.swaps = n
+	}
+}
+
+// Crossover is an optional configuration option which changes the operator
+// used to mate candidates. Defaults to CycleCrossover.
+func Crossover(op CrossoverOperator) Config {
+	return func(c *Scheduler) {
+		c.crossover = op
+	}
+}
+
+// HallOfFameSize is an optional configuration option which changes the number
+// of best candidates the genetic algorithm keeps track of.
+func HallOfFameSize(n uint) Config {
+	return func(c *Scheduler) {
+		c.hofSize = n
+	}
+}
+
+// newGA instantiates the genetic algorithm with the configuration options of
+// the Scheduler applied on top of eaopt's defaults.
+func (s *Scheduler) newGA(rng *rand.Rand) (*eaopt.GA, error) {
+	config := eaopt.NewDefaultGAConfig()
+	config.NGenerations = s.ngenerations
+	config.RNG = rng
+	if s.popSize > 0 {
+		config.PopSize = s.popSize
+	}
+	if s.npops > 0 {
+		config.NPops = s.npops
+	}
+	if s.migFrequency > 0 {
+		config.MigFrequency = s.migFrequency
+		config.Migrator = eaopt.MigRing{NMigrants: s.migrants}
+	}
+	if s.hofSize > 0 {
+		config.HofSize = s.hofSize
+	}
+
+	model := eaopt.ModGenerational{
+		Selector:  eaopt.SelTournament{NContestants: 3},
+		MutRate:   0.5,
+		CrossRate: 0.7,
+	}
+	if s.selector != nil {
+		model.Selector = s.selector
+	}
+	if s.mutRate != nil {
+		model.MutRate = *s.mutRate
+	}
+	if s.crossRate != nil {
+		model.CrossRate = *s.crossRate
+	}
+	config.Model = model
+
+	return config.NewGA()
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+func TestTuningOptionsAreApplied(t *testing.T) {
+	scheduler, err := New(time.Now(), nil,
+		NGenerations(42),
+		PopulationSize(50),
+		NPopulations(4),
+		Migration(10, 2),
+		Selection(eaopt.SelElitism{}),
+		MutationRate(0.2),
+		CrossoverRate(0.9),
+		HallOfFameSize(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ga, err := scheduler.newGA(rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ga.NGenerations != 42 || ga.PopSize != 50 || ga.NPops != 4 || ga.HofSize != 3 {
+		t.Error("Unexpected GA configuration:", ga.NGenerations, ga.PopSize, ga.NPops, ga.HofSize)
+	}
+	if ga.MigFrequency != 10 || ga.Migrator != (eaopt.MigRing{NMigrants: 2}) {
+		t.Error("Unexpected migration configuration:", ga.MigFrequency, ga.Migrator)
+	}
+	model, ok := ga.Model.(eaopt.ModGenerational)
+	if !ok {
+		t.Fatal("Unexpected model:", ga.Model)
+	}
+	if _, ok := model.Selector.(eaopt.SelElitism); !ok || model.MutRate != 0.2 || model.CrossRate != 0.9 {
+		t.Error("Unexpected model configuration:", model)
+	}
+}
+
+func TestRunWithTunedGA(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar}
+	attendee3 := Attendee{ID: "c", Calendar: emptyCalendar}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 30 * time.Minute, Attendees: []Attendee{attendee1, attendee2, attendee3}, PossibleRooms: rooms},
+	}
+
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	for _, op := range []CrossoverOperator{CycleCrossover, PartiallyMappedCrossover, OrderedCrossover} {
+		// A single request has a single gene to cross over.
+		for _, reqs := range [][]*ScheduleRequest{reqs, reqs[:1]} {
+			scheduler, err := New(now, reqs, NGenerations(50), Crossover(op), MutationSwaps(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := scheduler.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Events) != len(reqs) {
+				t.Error("Expected all", len(reqs), "requests to be scheduled with crossover", op)
+			}
+		}
+	}
+}
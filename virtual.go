@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+)
+
+// MeetingMode is how the attendees of a meeting meet.
+type MeetingMode int
+
+const (
+	// InPerson meetings take place in a room. Attendees that are Remote join
+	// over video, which makes the meeting hybrid. A meeting where all
+	// attendees are Remote doesn't need a room.
+	InPerson MeetingMode = iota
+	// Virtual meetings take place over video only and never get a room.
+	Virtual
+	// RoomOptional meetings get a room if one is available when the
+	// attendees are, and take place over video otherwise.
+	RoomOptional
+)
+
+// seats returns the number of attendees of req that need a seat in a room.
+// Picked pool members are assumed to be on-site.
+func (req *ScheduleRequest) seats() int {
+	count := 0
+	for _, a := range req.Attendees {
+		if !a.Remote {
+			count++
+		}
+	}
+	for _, pool := range req.Pools {
+		count += pool.Min
+	}
+	return count
+}
+
+// needsRoom checks if req should be placed in a room.
+func (req *ScheduleRequest) needsRoom() bool {
+	return req.Mode != Virtual && req.seats() > 0
+}
+
+// needsVideoLink checks if anyone attends se over video.
+func (se ScheduledEvent) needsVideoLink() bool {
+	if se.Room.ID == "" {
+		return true
+	}
+	for _, a := range se.Attendees {
+		if a.Remote {
+			return true
+		}
+	}
+	return false
+}
+
+// VideoLinkFunc generates a link to the video call of a meeting.
+type VideoLinkFunc func(ctx context.Context, event ScheduledEvent) (string, error)
+
+// VideoLinks is an optional configuration option which sets a generator of
+// links to video calls. It's called for every scheduled meeting that is
+// virtual or has remote attendees once the schedule is done. If the context
+// given to RunContext is done, no more links are generated and the events
+// without links are returned as they are.
+func VideoLinks(f VideoLinkFunc) Config {
+	return func(c *Scheduler) {
+		c.videoLinks = f
+	}
+}
+
+// addVideoLinks sets the VideoLink of the events that need one. On error, the
+// events are returned with the links that were generated before it.
+func (s *Scheduler) addVideoLinks(ctx context.Context, events []ScheduledEvent) ([]ScheduledEvent, error) {
+	if s.videoLinks == nil {
+		return events, nil
+	}
+	result := append([]ScheduledEvent(nil), events...)
+	for i, event := range result {
+		if !event.needsVideoLink() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		link, err := s.videoLinks(ctx, event)
+		if err != nil {
+			return result, err
+		}
+		result[i].VideoLink = link
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/k0kubun/pp/v3"
+)
+
+func TestVirtualMeetings(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	busyRoom := Room{ID: "busy", Calendar: FakeCalendar{{now, now.Add(8 * time.Hour)}}}
+	smallRoom := Room{ID: "small", Calendar: emptyCalendar, Capacity: 1}
+	attendee := func(id AttendeeID, remote bool) Attendee {
+		return Attendee{ID: id, Calendar: emptyCalendar, Remote: remote}
+	}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee("a", false)}, Mode: Virtual},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee("b", false), attendee("c", true)}, PossibleRooms: []Room{smallRoom}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee("d", false)}, PossibleRooms: []Room{busyRoom}, Mode: RoomOptional},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee("e", true), attendee("f", true)}},
+		{Length: 60 * time.Minute, Attendees: []Attendee{attendee("g", false)}, PossibleRooms: []Room{busyRoom}},
+	}
+
+	links := func(ctx context.Context, event ScheduledEvent) (string, error) {
+		return fmt.Sprint("https://video.example.com/", event.Attendees[0].ID), nil
+	}
+	scheduler, err := New(now, reqs, NGenerations(5), Seed(1), VideoLinks(links))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scheduler.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[*ScheduleRequest]struct {
+		room  RoomID
+		start time.Time
+		link  string
+	}{
+		reqs[0]: {"", now, "https://video.example.com/a"},
+		reqs[1]: {"small", now, "https://video.example.com/b"},
+		reqs[2]: {"", now, "https://video.example.com/d"},
+		reqs[3]: {"", now, "https://video.example.com/e"},
+		reqs[4]: {"busy", now.Add(8 * time.Hour), ""},
+	}
+	if len(result.Events) != len(expected) {
+		t.Fatalf("Unexpected events:\n%s", pp.Sprint(result.Events))
+	}
+	for _, event := range result.Events {
+		e := expected[event.Request]
+		if event.Room.ID != e.room || !event.Start.Equal(e.start) || event.VideoLink != e.link {
+			t.Errorf("Unexpected event. Expected: %+v Was:\n%s", e, pp.Sprint(event))
+		}
+	}
+}
+
+func TestVideoLinksWhenCancelled(t *testing.T) {
+	// Monday morning at 9.
+	now, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	emptyCalendar := FakeCalendar{}
+	reqs := []*ScheduleRequest{
+		{Length: 60 * time.Minute, Attendees: []Attendee{{ID: "a", Calendar: emptyCalendar}}, Mode: Virtual},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	links := func(ctx context.Context, event ScheduledEvent) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "https://video.example.com/a", nil
+	}
+	cancelling := func(Progress) { cancel() }
+	scheduler, err := New(now, reqs, NGenerations(1<<31), VideoLinks(links), WithProgress(cancelling))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scheduler.RunContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != Cancelled {
+		t.Error("Unexpected stop reason. Expected:", Cancelled, "Was:", result.StopReason)
+	}
+	if len(result.Events) != 1 || result.Events[0].VideoLink != "" {
+		t.Errorf("Expected the best schedule without links:\n%s", pp.Sprint(result.Events))
+	}
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"time"
+)
+
+// WeeklyWindow is a window of time that recurs every week on a specific
+// weekday.
+type WeeklyWindow struct {
+	// Weekday is the day of the week the window recurs on.
+	Weekday time.Weekday
+	// Start is the offset from local midnight at which the window opens.
+	// Inclusive.
+	Start time.Duration
+	// End is the offset from local midnight at which the window closes.
+	// Exclusive. Must be strictly after Start and at most 24 hours.
+	End time.Duration
+}
+
+// WorkingHours is a weekly pattern of windows during which an attendee can
+// attend meetings. Meetings are never placed outside of them.
+type WorkingHours struct {
+	// Location is the time zone in which Windows are expressed. If nil, the
+	// attendee's Location is used.
+	Location *time.Location
+	// Windows are the weekly recurring windows during which meetings can be
+	// placed.
+	Windows []WeeklyWindow
+}
+
+// WeekdayWorkingHours returns WorkingHours which are open between start and
+// end (offsets from local midnight) Monday to Friday in loc. If loc is nil, the
+// attendee's Location is used.
+func WeekdayWorkingHours(loc *time.Location, start, end time.Duration) *WorkingHours {
+	wh := WorkingHours{Location: loc}
+	for d := time.Monday; d <= time.Friday; d++ {
+		wh.Windows = append(wh.Windows, WeeklyWindow{d, start, end})
+	}
+	return &wh
+}
+
+// on returns the occurrence of the window on the calendar day of date in loc.
+// It returns false if the window doesn't recur on that weekday.
+func (w WeeklyWindow) on(date time.Time, loc *time.Location) (TimeInterval, bool) {
+	date = date.In(loc)
+	if date.Weekday() != w.Weekday {
+		return TimeInterval{}, false
+	}
+	return TimeInterval{
+		time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, int(w.Start), loc),
+		time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, int(w.End), loc),
+	}, true
+}
+
+// occurrences returns all occurrences of windows, interpreted in loc, that
+// overlap with ti.
+func occurrences(windows []WeeklyWindow, ti TimeInterval, loc *time.Location) []TimeInterval {
+	var result []TimeInterval
+	for date := localMidnight(ti.Start, loc); date.Before(ti.End); date = date.AddDate(0, 0, 1) {
+		for _, w := range windows {
+			if occurrence, ok := w.on(date, loc); ok && occurrence.Overlaps(ti) {
+				result = append(result, occurrence)
+			}
+		}
+	}
+	return result
+}
+
+// localMidnight returns the start of the calendar day of t in loc.
+func localMidnight(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// nextFit returns the earliest start time at or after ti.Start at which an
+// interval with the same length as ti fits entirely within a single window
+// interpreted in loc. It returns false if no window is long enough to ever fit
+// it.
+func (wh *WorkingHours) nextFit(ti TimeInterval, loc *time.Location) (time.Time, bool) {
+	length := ti.End.Sub(ti.Start)
+	local := ti.Start.In(loc)
+
+	// A week and a day is enough to wrap around to the same weekday again.
+	for day := 0; day <= 7; day++ {
+		var best *time.Time
+		for _, w := range wh.Windows {
+			occurrence, ok := w.on(local.AddDate(0, 0, day), loc)
+			if !ok {
+				continue
+			}
+			start := latest(occurrence.Start, ti.Start)
+			if start.Add(length).After(occurrence.End) {
+				continue
+			}
+			if best == nil || start.Before(*best) {
+				best = &start
+			}
+		}
+		if best != nil {
+			return *best, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// workingTime returns how much of ti is within the windows interpreted in loc.
+func (wh *WorkingHours) workingTime(ti TimeInterval, loc *time.Location) time.Duration {
+	return timeWithin(wh.Windows, ti, loc)
+}
+
+// timeWithin returns how much of ti is within windows interpreted in loc.
+func timeWithin(windows []WeeklyWindow, ti TimeInterval, loc *time.Location) time.Duration {
+	var total time.Duration
+	for _, window := range occurrences(windows, ti, loc) {
+		total += earliestOf(window.End, ti.End).Sub(latest(window.Start, ti.Start))
+	}
+	return total
+}
@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkingHoursNextFit(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+	wh := WeekdayWorkingHours(stockholm, 9*time.Hour, 17*time.Hour)
+
+	at := func(s string) time.Time {
+		t, _ := time.ParseInLocation("02-01-2006 15:04", s, stockholm)
+		return t
+	}
+	tests := []struct {
+		name     string
+		start    time.Time
+		expected time.Time
+	}{
+		{"within window", at("02-12-2019 10:00"), at("02-12-2019 10:00")},
+		{"before window", at("02-12-2019 03:00"), at("02-12-2019 09:00")},
+		{"overlapping end of window", at("02-12-2019 16:45"), at("03-12-2019 09:00")},
+		{"friday evening", at("06-12-2019 18:00"), at("09-12-2019 09:00")},
+		{"weekend", at("07-12-2019 11:00"), at("09-12-2019 09:00")},
+	}
+	for _, test := range tests {
+		next, ok := wh.nextFit(TimeInterval{test.start, test.start.Add(30 * time.Minute)}, stockholm)
+		if !ok {
+			t.Errorf("%s: expected a fit", test.name)
+			continue
+		}
+		if !next.Equal(test.expected) {
+			t.Errorf("%s: Expected: %s Was: %s", test.name, test.expected, next)
+		}
+	}
+
+	if _, ok := wh.nextFit(TimeInterval{at("02-12-2019 09:00"), at("02-12-2019 18:00")}, stockholm); ok {
+		t.Error("expected a meeting longer than any window to never fit")
+	}
+}
+
+func TestSchedulingWithinWorkingHours(t *testing.T) {
+	emptyCalendar := FakeCalendar{}
+	rooms := []Room{
+		{ID: "room-1", Calendar: emptyCalendar},
+	}
+	wh := WeekdayWorkingHours(time.UTC, 9*time.Hour, 17*time.Hour)
+	attendee1 := Attendee{ID: "a", Calendar: emptyCalendar, WorkingHours: wh}
+	attendee2 := Attendee{ID: "b", Calendar: emptyCalendar, WorkingHours: wh}
+	reqs := []*ScheduleRequest{
+		{Length: 6 * time.Hour, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+		{Length: 6 * time.Hour, Attendees: []Attendee{attendee1, attendee2}, PossibleRooms: rooms},
+	}
+
+	// Saturday at midnight.
+	now, _ := time.Parse("02-01-2006 15:04", "30-11-2019 00:00")
+
+	scheduler, err := New(now, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sol := candidate{scheduler: scheduler, order: []int{0, 1}}
+	schedule, err := sol.Schedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monday, _ := time.Parse("02-01-2006 15:04", "02-12-2019 09:00")
+	expected := []time.Time{monday, monday.AddDate(0, 0, 1)}
+	for i, e := range expected {
+		if s := schedule.Events[i].Start; !s.Equal(e) {
+			t.Errorf("Unexpected start of event %d. Expected: %s Was: %s", i, e, s)
+		}
+	}
+}